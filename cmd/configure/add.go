@@ -7,11 +7,15 @@ master my life.
 package configure
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
 	"github.com/AnyoneClown/anydb/utils"
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -40,12 +44,17 @@ type addModel struct {
 	inputs     []textinput.Model
 	cursorMode cursor.Mode
 	errors     []string
+
+	// keepPassword lets the password stay empty when editing a config that
+	// already has one, the stored password is kept then.
+	keepPassword bool
+	cancelled    bool
 }
 
 func initialModel() addModel {
 	m := addModel{
-		inputs: make([]textinput.Model, 7),
-		errors: make([]string, 7),
+		inputs: make([]textinput.Model, 15),
+		errors: make([]string, 15),
 	}
 
 	var t textinput.Model
@@ -77,11 +86,41 @@ func initialModel() addModel {
 			t.EchoCharacter = '•'
 			t.Validate = utils.ValidateNotEmpty
 		case 5:
-			t.Placeholder = "Database"
+			t.Placeholder = "Database (file path for sqlite)"
+			t.CharLimit = 256
 			t.Validate = utils.ValidateNotEmpty
 		case 6:
 			t.Placeholder = "Database driver"
 			t.Validate = utils.ValidateDatabaseDriver
+		case 7:
+			t.Placeholder = "SSL mode (optional)"
+			t.Validate = utils.ValidateSSLMode
+		case 8:
+			t.Placeholder = "SSL root CA path (optional)"
+			t.CharLimit = 256
+			t.Validate = utils.ValidateOptionalFile
+		case 9:
+			t.Placeholder = "SSL client cert path (optional)"
+			t.CharLimit = 256
+			t.Validate = utils.ValidateOptionalFile
+		case 10:
+			t.Placeholder = "SSL client key path (optional)"
+			t.CharLimit = 256
+			t.Validate = utils.ValidateOptionalFile
+		case 11:
+			t.Placeholder = "Password reference, e.g. env:PGPASSWORD (optional)"
+			t.CharLimit = 256
+			t.Validate = utils.ValidatePasswordRef
+		case 12:
+			t.Placeholder = "Environment, e.g. dev, staging or prod (optional)"
+		case 13:
+			t.Placeholder = "Color, e.g. #dc2626 (optional)"
+			t.CharLimit = 7
+			t.Validate = utils.ValidateColor
+		case 14:
+			t.Placeholder = "Read-only (yes/no)"
+			t.CharLimit = 5
+			t.Validate = utils.ValidateYesNo
 		}
 
 		m.inputs[i] = t
@@ -90,6 +129,53 @@ func initialModel() addModel {
 	return m
 }
 
+// editModel returns the form prefilled with the values of cfg. The stored
+// password is encrypted, so it is not shown; leaving it empty keeps it.
+func editModel(cfg config.DBConfig) addModel {
+	m := initialModel()
+	m.keepPassword = cfg.Password != "" || cfg.PasswordRef != ""
+	if m.keepPassword {
+		m.inputs[4].Placeholder = "Password (leave empty to keep)"
+	}
+
+	m.inputs[0].SetValue(cfg.ConfigName)
+	m.inputs[1].SetValue(cfg.Host)
+	if cfg.Port != 0 {
+		m.inputs[2].SetValue(strconv.Itoa(cfg.Port))
+	}
+	m.inputs[3].SetValue(cfg.User)
+	m.inputs[5].SetValue(cfg.Database)
+	m.inputs[6].SetValue(cfg.Driver)
+	m.inputs[7].SetValue(cfg.SSLMode)
+	m.inputs[8].SetValue(cfg.SSLRootCert)
+	m.inputs[9].SetValue(cfg.SSLCert)
+	m.inputs[10].SetValue(cfg.SSLKey)
+	m.inputs[11].SetValue(cfg.PasswordRef)
+	m.inputs[12].SetValue(cfg.Environment)
+	m.inputs[13].SetValue(cfg.Color)
+	if cfg.ReadOnly {
+		m.inputs[14].SetValue("yes")
+	}
+
+	return m
+}
+
+// inputError validates a single input. Host, port, user and password are
+// not used by file based drivers such as sqlite, so they may stay empty, and
+// the password is not needed when a password reference is given.
+func (m addModel) inputError(i int) error {
+	if i >= 1 && i <= 4 && dialect.IsFileBased(m.inputs[6].Value()) {
+		return nil
+	}
+	if i == 4 && (m.inputs[11].Value() != "" || m.keepPassword) {
+		return nil
+	}
+	if m.inputs[i].Validate == nil {
+		return nil
+	}
+	return m.inputs[i].Validate(m.inputs[i].Value())
+}
+
 func (m addModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -99,6 +185,7 @@ func (m addModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc":
+			m.cancelled = true
 			return m, tea.Quit
 
 		case "ctrl+r":
@@ -158,7 +245,10 @@ func (m *addModel) updateInputs(msg tea.Msg) tea.Cmd {
 
 	for i := range m.inputs {
 		m.inputs[i], cmds[i] = m.inputs[i].Update(msg)
-		if err := m.inputs[i].Validate(m.inputs[i].Value()); err != nil {
+	}
+
+	for i := range m.inputs {
+		if err := m.inputError(i); err != nil {
 			m.errors[i] = err.Error()
 		} else {
 			m.errors[i] = ""
@@ -206,57 +296,186 @@ func (m addModel) View() string {
 	return b.String()
 }
 
-var addCmd = &cobra.Command{
-	Use:   "add",
-	Short: "Add new database configuration",
+// valid reports whether the form was submitted with every input valid.
+func (m addModel) valid() bool {
+	if m.cancelled {
+		return false
+	}
+	for i := range m.inputs {
+		if err := m.inputError(i); err != nil {
+			return false
+		}
+	}
+	return true
+}
 
-	Run: func(cmd *cobra.Command, args []string) {
-		m := initialModel()
+// dbConfig builds a config from the inputs, leaving its ID empty.
+func (m addModel) dbConfig() config.DBConfig {
+	port, _ := strconv.Atoi(m.inputs[2].Value())
 
-		addStart := tea.NewProgram(m)
-		result, err := addStart.Run()
-		if err != nil {
-			utils.Log.Error("Could not start program", zap.Error(err))
-			os.Exit(1)
-		}
+	readOnly, _ := utils.ParseYesNo(m.inputs[14].Value())
+
+	return utils.NormalizeConfig(config.DBConfig{
+		ConfigName: m.inputs[0].Value(),
+		Driver:     m.inputs[6].Value(),
+		Host:       m.inputs[1].Value(),
+		Port:       port,
+		User:       m.inputs[3].Value(),
+		Password:   m.inputs[4].Value(),
+		Database:   m.inputs[5].Value(),
+
+		PasswordRef: m.inputs[11].Value(),
+
+		SSLMode:     m.inputs[7].Value(),
+		SSLRootCert: m.inputs[8].Value(),
+		SSLCert:     m.inputs[9].Value(),
+		SSLKey:      m.inputs[10].Value(),
+
+		Environment: m.inputs[12].Value(),
+		Color:       m.inputs[13].Value(),
+		ReadOnly:    readOnly,
+	})
+}
+
+// configFlags select the non-interactive mode of the add command.
+var configFlags = []string{"url", "name", "driver", "host", "port", "user", "password-stdin", "password-ref", "database", "environment", "color", "read-only"}
+
+func newAddCmd(store utils.ConfigStore) *cobra.Command {
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add new database configuration",
+		Long: `Add a new database configuration through an interactive form.
+
+Passing any of the flags below skips the form, for scripts and CI machines.
+Start from --url and override single values with the other flags, or pass
+them all one by one. The password is read from stdin with --password-stdin:
+
+  echo "$PGPASSWORD" | anydb configure add --name prod --password-stdin \
+    --url "postgres://app@db.internal:5432/app?sslmode=require"`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			var newConfig config.DBConfig
+			var err error
+
+			if hasConfigFlags(cmd) {
+				newConfig, err = configFromFlags(cmd, os.Stdin)
+				if err != nil {
+					utils.Log.Error("Invalid configuration", zap.Error(err))
+					os.Exit(1)
+				}
+			} else {
+				result, err := tea.NewProgram(initialModel()).Run()
+				if err != nil {
+					utils.Log.Error("Could not start program", zap.Error(err))
+					os.Exit(1)
+				}
 
-		m = result.(addModel)
+				m := result.(addModel)
+				if !m.valid() {
+					return
+				}
+				newConfig = m.dbConfig()
+			}
+			newConfig.ID = uuid.New()
 
-		for _, input := range m.inputs {
-			if err := input.Validate(input.Value()); err != nil {
-				return
+			newConfig.Password, err = store.EncryptPassword(newConfig.Password)
+			if err != nil {
+				utils.Log.Error("Failed to encrypt password", zap.Error(err))
+				os.Exit(1)
 			}
+
+			err = store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+				return append(configs, newConfig), nil
+			})
+			if err != nil {
+				utils.Log.Error("Failed to save configuration", zap.Error(err))
+				os.Exit(1)
+			}
+			fmt.Println("Configuration saved successfully.")
+		},
+	}
+
+	addCmd.Flags().String("url", "", "Connection URL, e.g. postgres://user@host:5432/db?sslmode=require")
+	addCmd.Flags().String("name", "", "Config name")
+	addCmd.Flags().String("driver", "", "Database driver ("+strings.Join(dialect.Drivers(), ", ")+")")
+	addCmd.Flags().String("host", "", "Database host")
+	addCmd.Flags().Int("port", 0, "Database port (default of the driver)")
+	addCmd.Flags().String("user", "", "Database user")
+	addCmd.Flags().Bool("password-stdin", false, "Read the password from stdin")
+	addCmd.Flags().String("password-ref", "", "Password reference resolved when connecting, e.g. env:PGPASSWORD")
+	addCmd.Flags().String("database", "", "Database name, or file path for sqlite")
+	addCmd.Flags().String("environment", "", "Environment label, e.g. dev, staging or prod")
+	addCmd.Flags().String("color", "", "Color of the environment label, e.g. #dc2626")
+	addCmd.Flags().Bool("read-only", false, "Open every session of this config read-only")
+
+	addCmd.Flags().BoolP("help", "h", false, "help for add")
+	addCmd.Flags().MarkHidden("help")
+	return addCmd
+}
+
+func hasConfigFlags(cmd *cobra.Command) bool {
+	for _, name := range configFlags {
+		if cmd.Flags().Changed(name) {
+			return true
 		}
+	}
+	return false
+}
+
+// configFromFlags builds the config given on the command line and validates
+// it like the form does. Flags override the values taken from --url.
+func configFromFlags(cmd *cobra.Command, stdin io.Reader) (config.DBConfig, error) {
+	flags := cmd.Flags()
 
-		configName := m.inputs[0].Value()
-		host := m.inputs[1].Value()
-		port := m.inputs[2].Value()
-		user := m.inputs[3].Value()
-		password := m.inputs[4].Value()
-		database := m.inputs[5].Value()
-		databaseDriver := m.inputs[6].Value()
-
-		newConfig := config.DBConfig{
-			ID:         uuid.New(),
-			ConfigName: configName,
-			Driver:     databaseDriver,
-			Host:       host,
-			Port:       port,
-			User:       user,
-			Password:   password,
-			Database:   database,
+	var cfg config.DBConfig
+	if rawURL, _ := flags.GetString("url"); rawURL != "" {
+		var err error
+		cfg, err = utils.ParseConfigURL(rawURL)
+		if err != nil {
+			return config.DBConfig{}, err
 		}
+	}
 
-		config.Configs = append(config.Configs, newConfig)
-		if err := utils.SaveConfigs(config.Configs, config.ConfigFile); err != nil {
-			utils.Log.Error("Failed to save configuration", zap.Error(err))
-		} else {
-			fmt.Println("Configuration saved successfully.")
+	stringFlags := map[string]*string{
+		"name":         &cfg.ConfigName,
+		"driver":       &cfg.Driver,
+		"host":         &cfg.Host,
+		"user":         &cfg.User,
+		"password-ref": &cfg.PasswordRef,
+		"database":     &cfg.Database,
+		"environment":  &cfg.Environment,
+		"color":        &cfg.Color,
+	}
+	for name, value := range stringFlags {
+		if flags.Changed(name) {
+			*value, _ = flags.GetString(name)
 		}
-	},
-}
+	}
+	cfg.Driver = strings.ToLower(cfg.Driver)
+	cfg.ReadOnly, _ = flags.GetBool("read-only")
 
-func init() {
-	addCmd.Flags().BoolP("help", "h", false, "help for add")
-	addCmd.Flags().MarkHidden("help")
+	if flags.Changed("port") {
+		cfg.Port, _ = flags.GetInt("port")
+	} else if cfg.Port == 0 {
+		cfg.Port = dialect.DefaultPort(cfg.Driver)
+	}
+
+	if passwordStdin, _ := flags.GetBool("password-stdin"); passwordStdin {
+		password, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return config.DBConfig{}, fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		cfg.Password = strings.TrimRight(password, "\r\n")
+	}
+
+	cfg = utils.NormalizeConfig(cfg)
+	if err := utils.ValidateConfig(cfg); err != nil {
+		return config.DBConfig{}, err
+	}
+	for _, file := range []string{cfg.SSLRootCert, cfg.SSLCert, cfg.SSLKey} {
+		if err := utils.ValidateOptionalFile(file); err != nil {
+			return config.DBConfig{}, err
+		}
+	}
+	return cfg, nil
 }
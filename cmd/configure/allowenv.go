@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newAllowEnvCmd(store utils.ConfigStore) *cobra.Command {
+	allowEnvCmd := &cobra.Command{
+		Use:   "allow-env [NAME...]",
+		Short: "Allow project files and the web API to reference environment variables as passwords",
+		Long: `Project files come with the repositories they are committed to, so their
+passwordRef can only read the environment variables allowed here. Otherwise any
+repository could send a token such as GITHUB_TOKEN to a server of its choosing
+as a database password. The same goes for configs created through the web API
+of anydb run.
+
+Without names the allowed variables are listed:
+
+  anydb configure allow-env PGPASSWORD
+  anydb configure allow-env --remove PGPASSWORD`,
+		Run: func(cmd *cobra.Command, args []string) {
+			remove, _ := cmd.Flags().GetBool("remove")
+
+			if len(args) > 0 {
+				err := store.UpdateAllowedEnv(func(names []string) ([]string, error) {
+					if remove {
+						return slices.DeleteFunc(names, func(name string) bool { return slices.Contains(args, name) }), nil
+					}
+					return append(names, args...), nil
+				})
+				if err != nil {
+					utils.Log.Error("Failed to save allowed variables", zap.Error(err))
+					os.Exit(1)
+				}
+			}
+
+			names, err := store.AllowedEnv()
+			if err != nil {
+				utils.Log.Error("Failed to load allowed variables", zap.Error(err))
+				os.Exit(1)
+			}
+			if len(names) == 0 {
+				fmt.Println("Project files and the web API cannot reference environment variables.")
+				return
+			}
+			fmt.Printf("Project files and the web API can reference %s.\n", strings.Join(names, ", "))
+		},
+	}
+
+	allowEnvCmd.Flags().Bool("remove", false, "Disallow the named variables")
+	return allowEnvCmd
+}
@@ -8,41 +8,78 @@ package configure
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/utils"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
-var ConfigureCmd = &cobra.Command{
-	Use:   "configure",
-	Short: "Configure your database credentials",
-	Long:  `Use it to choose database credentials. You can add, edit, remove, and list your configurations!`,
-	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(newModel("Database configuration"), tea.WithAltScreen())
+// NewConfigureCmd builds the configure command and its subcommands on top of store.
+func NewConfigureCmd(store utils.ConfigStore) *cobra.Command {
+	configureCmd := &cobra.Command{
+		Use:   "configure",
+		Short: "Configure your database credentials",
+		Long: `Use it to choose database credentials. You can add, edit, remove, and list your configurations!
 
-		finalModel, err := p.Run()
-		if err != nil {
-			fmt.Printf("Error running program: %v\n", err)
-			return
-		}
+A project can keep its own configurations in a .anydb.yaml file, found in the
+working directory or any of its parents. They are listed with the global
+ones, and the project default takes precedence over the global default:
 
-		if finalModel.(model).choice != nil {
-			choice := finalModel.(model).choice
-			fmt.Printf("Selected configuration: %s\n", choice.ConfigName)
+  default: api
+  configs:
+    - configName: api
+      driver: postgres
+      host: localhost
+      port: 5432
+      user: api
+      passwordRef: env:PGPASSWORD
+      database: api
+    - ref: shared-staging
 
-			data, err := yaml.Marshal(choice)
+Project configurations cannot store passwords, use passwordRef instead. Only
+files inside the project and the environment variables allowed with
+anydb configure allow-env can be referenced there.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			configs, err := store.LoadConfigs()
 			if err != nil {
+				fmt.Printf("Failed to load configurations: %v\n", err)
 				return
 			}
-			os.WriteFile(config.DefaultConfigFile, data, 0644)
-		}
-	},
-}
 
-func init() {
-	ConfigureCmd.AddCommand(addCmd)
-	ConfigureCmd.AddCommand(removeCmd)
+			p := tea.NewProgram(newModel("Database configuration", configs), tea.WithAltScreen())
+
+			finalModel, err := p.Run()
+			if err != nil {
+				fmt.Printf("Error running program: %v\n", err)
+				return
+			}
+
+			if finalModel.(model).choice != nil {
+				choice := finalModel.(model).choice
+				if err := utils.CheckGlobalConfig(*choice); err != nil {
+					fmt.Printf("Failed to save default configuration: %v\n", err)
+					return
+				}
+				fmt.Printf("Selected configuration: %s\n", choice.ConfigName)
+
+				if err := store.SetDefaultConfig(choice.ID); err != nil {
+					fmt.Printf("Failed to save default configuration: %v\n", err)
+				}
+				if active, err := store.LoadDefaultConfig(); err == nil && active.ID != choice.ID {
+					fmt.Printf("%s stays the default of this project, set in %s\n", active.ConfigName, store.ProjectFile())
+				}
+			}
+		},
+	}
+
+	configureCmd.AddCommand(newAddCmd(store))
+	configureCmd.AddCommand(newAllowEnvCmd(store))
+	configureCmd.AddCommand(newEditCmd(store))
+	configureCmd.AddCommand(newExportCmd(store))
+	configureCmd.AddCommand(newImportCmd(store))
+	configureCmd.AddCommand(newListCmd(store))
+	configureCmd.AddCommand(newRemoveCmd(store))
+	configureCmd.AddCommand(newRekeyCmd(store))
+	configureCmd.AddCommand(newTestCmd(store))
+	return configureCmd
 }
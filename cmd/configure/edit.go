@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newEditCmd(store utils.ConfigStore) *cobra.Command {
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit database configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			configs, err := store.LoadConfigs()
+			if err != nil {
+				fmt.Printf("Failed to load configurations: %v\n", err)
+				return
+			}
+
+			p := tea.NewProgram(newModel("Edit configuration", configs), tea.WithAltScreen())
+
+			finalModel, err := p.Run()
+			if err != nil {
+				fmt.Printf("Error running program: %v\n", err)
+				return
+			}
+
+			choice := finalModel.(model).choice
+			if choice == nil {
+				return
+			}
+			if err := utils.CheckGlobalConfig(*choice); err != nil {
+				utils.Log.Error("Configuration cannot be edited", zap.Error(err))
+				os.Exit(1)
+			}
+			if err := utils.ConfirmProtected(*choice, "edit", os.Stdin, os.Stderr); err != nil {
+				utils.Log.Error("Edit not confirmed", zap.Error(err))
+				os.Exit(1)
+			}
+
+			result, err := tea.NewProgram(editModel(*choice)).Run()
+			if err != nil {
+				utils.Log.Error("Could not start program", zap.Error(err))
+				os.Exit(1)
+			}
+
+			m := result.(addModel)
+			if !m.valid() {
+				return
+			}
+
+			editedConfig := m.dbConfig()
+			editedConfig.ID = choice.ID
+
+			// An empty password keeps the stored one instead of clearing it
+			if editedConfig.Password == "" && editedConfig.PasswordRef == "" {
+				editedConfig.Password = choice.Password
+				editedConfig.PasswordRef = choice.PasswordRef
+			}
+
+			editedConfig.Password, err = store.EncryptPassword(editedConfig.Password)
+			if err != nil {
+				utils.Log.Error("Failed to encrypt password", zap.Error(err))
+				os.Exit(1)
+			}
+
+			err = store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+				for i, cfg := range configs {
+					if cfg.ID == editedConfig.ID {
+						// Keep changes saved while the form was open
+						if utils.ConfigRevision(cfg) != utils.ConfigRevision(*choice) {
+							return nil, fmt.Errorf("%w, run anydb configure edit again", utils.ErrRevisionMismatch)
+						}
+						configs[i] = editedConfig
+						return configs, nil
+					}
+				}
+				return nil, fmt.Errorf("%w: %s", utils.ErrConfigNotFound, editedConfig.ID)
+			})
+			if err != nil {
+				utils.Log.Error("Failed to save configuration", zap.Error(err))
+				os.Exit(1)
+			}
+
+			fmt.Printf("Updated configuration: %s\n", editedConfig.ConfigName)
+		},
+	}
+
+	editCmd.Flags().BoolP("help", "h", false, "help for edit")
+	editCmd.Flags().MarkHidden("help")
+	return editCmd
+}
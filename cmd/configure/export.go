@@ -0,0 +1,252 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// exportFormats maps the --format values exporting a single config to their
+// formatters. The config passed in holds the plaintext password, which is
+// empty with --no-password.
+var exportFormats = map[string]func(cfg config.DBConfig) (string, error){
+	"dsn":    exportDSN,
+	"pgpass": exportPgpass,
+	"env":    exportEnv,
+	"psql":   exportPsql,
+	"jdbc":   exportJDBC,
+}
+
+func newExportCmd(store utils.ConfigStore) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export [name]",
+		Short: "Print a configuration in formats other tools understand",
+		Long: `Print the connection of a configuration as:
+
+  dsn     the connection string anydb passes to the driver
+  pgpass  a line of a .pgpass file
+  env     DATABASE_URL and the PG* variables, ready to be sourced
+  psql    a psql command line
+  jdbc    a JDBC URL
+
+Without a name the configuration selected by --config, ANYDB_CONFIG or as
+default is exported. Passwords are included unless --no-password is given.
+
+With --all --format bundle every configuration is written as a file to share
+with a team. Passwords are stripped, or encrypted with a new passphrase with
+--encrypt. The bundle has the layout of the configuration file, so it can be
+copied to anydb-config.yaml in another home directory, the passphrase being
+its master passphrase.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			all, _ := cmd.Flags().GetBool("all")
+			noPassword, _ := cmd.Flags().GetBool("no-password")
+			encryptBundle, _ := cmd.Flags().GetBool("encrypt")
+
+			configs, err := targetConfigs(cmd, store, args, all)
+			if err != nil {
+				utils.Log.Error("Failed to select configurations", zap.Error(err))
+				os.Exit(1)
+			}
+
+			if format == "bundle" {
+				passphrase := ""
+				if encryptBundle {
+					passphrase, err = utils.ReadNewPassphrase()
+					if err != nil {
+						utils.Log.Error("Failed to read bundle passphrase", zap.Error(err))
+						os.Exit(1)
+					}
+				}
+				data, err := utils.ExportBundle(store, configs, passphrase)
+				if err != nil {
+					utils.Log.Error("Failed to export configurations", zap.Error(err))
+					os.Exit(1)
+				}
+				os.Stdout.Write(data)
+				return
+			}
+
+			exportFormat, ok := exportFormats[format]
+			if !ok {
+				utils.Log.Error("Unsupported export format", zap.String("format", format))
+				os.Exit(1)
+			}
+			if len(configs) != 1 {
+				utils.Log.Error("Export one configuration at a time, --all needs --format bundle")
+				os.Exit(1)
+			}
+
+			cfg := configs[0]
+			cfg.Password = ""
+			if !noPassword {
+				cfg.Password, err = utils.ResolvePassword(store, configs[0])
+				if err != nil {
+					utils.Log.Error("Failed to resolve password", zap.Error(err))
+					os.Exit(1)
+				}
+			}
+
+			output, err := exportFormat(cfg)
+			if err != nil {
+				utils.Log.Error("Failed to export configuration", zap.Error(err))
+				os.Exit(1)
+			}
+			fmt.Println(output)
+		},
+	}
+
+	formats := make([]string, 0, len(exportFormats)+1)
+	for format := range exportFormats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	formats = append(formats, "bundle")
+
+	exportCmd.Flags().StringP("format", "f", "dsn", "Output format ("+strings.Join(formats, ", ")+")")
+	exportCmd.Flags().Bool("all", false, "Export every configuration, with --format bundle")
+	exportCmd.Flags().Bool("no-password", false, "Leave the password out")
+	exportCmd.Flags().Bool("encrypt", false, "Encrypt the passwords of a bundle instead of stripping them")
+	return exportCmd
+}
+
+func exportDSN(cfg config.DBConfig) (string, error) {
+	d, err := dialect.Get(cfg.Driver)
+	if err != nil {
+		return "", err
+	}
+	return d.DSN(cfg)
+}
+
+func isPostgres(cfg config.DBConfig) bool {
+	return cfg.Driver == "postgres" || cfg.Driver == "cockroachdb"
+}
+
+func exportPgpass(cfg config.DBConfig) (string, error) {
+	if !isPostgres(cfg) {
+		return "", fmt.Errorf("the pgpass format needs a postgres or cockroachdb configuration, not %s", cfg.Driver)
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace
+	fields := []string{cfg.Host, strconv.Itoa(cfg.Port), cfg.Database, cfg.User, cfg.Password}
+	for i, field := range fields {
+		fields[i] = escape(field)
+	}
+	return strings.Join(fields, ":"), nil
+}
+
+func exportEnv(cfg config.DBConfig) (string, error) {
+	values := []struct{ key, value string }{
+		{"DATABASE_URL", utils.ConfigURL(cfg)},
+	}
+	if isPostgres(cfg) {
+		values = append(values, []struct{ key, value string }{
+			{"PGHOST", cfg.Host},
+			{"PGPORT", strconv.Itoa(cfg.Port)},
+			{"PGUSER", cfg.User},
+			{"PGPASSWORD", cfg.Password},
+			{"PGDATABASE", cfg.Database},
+			{"PGSSLMODE", cfg.SSLMode},
+			{"PGSSLROOTCERT", cfg.SSLRootCert},
+			{"PGSSLCERT", cfg.SSLCert},
+			{"PGSSLKEY", cfg.SSLKey},
+		}...)
+		if cfg.ReadOnly {
+			values = append(values, struct{ key, value string }{"PGOPTIONS", "-c default_transaction_read_only=on"})
+		}
+	}
+
+	var lines []string
+	for _, v := range values {
+		if v.value != "" {
+			lines = append(lines, fmt.Sprintf("export %s=%s", v.key, shellQuote(v.value)))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func exportPsql(cfg config.DBConfig) (string, error) {
+	if !isPostgres(cfg) {
+		return "", fmt.Errorf("the psql format needs a postgres or cockroachdb configuration, not %s", cfg.Driver)
+	}
+	cfg.Driver = "postgresql"
+	u, err := url.Parse(utils.ConfigURL(cfg))
+	if err != nil {
+		return "", err
+	}
+	if cfg.ReadOnly {
+		query := u.Query()
+		query.Set("options", "-c default_transaction_read_only=on")
+		u.RawQuery = query.Encode()
+	}
+	return "psql " + shellQuote(u.String()), nil
+}
+
+// jdbcSSLModes maps sslmode onto the sslMode values of the MySQL and
+// MariaDB connectors, the PostgreSQL one takes sslmode as it is.
+var jdbcSSLModes = map[string]map[string]string{
+	"mysql": {
+		"disable":     "DISABLED",
+		"require":     "REQUIRED",
+		"verify-ca":   "VERIFY_CA",
+		"verify-full": "VERIFY_IDENTITY",
+	},
+	"mariadb": {
+		"disable":     "disable",
+		"require":     "trust",
+		"verify-ca":   "verify-ca",
+		"verify-full": "verify-full",
+	},
+}
+
+func exportJDBC(cfg config.DBConfig) (string, error) {
+	if cfg.Driver == "sqlite" {
+		return "jdbc:sqlite:" + cfg.Database, nil
+	}
+
+	query := url.Values{}
+	query.Set("user", cfg.User)
+	if cfg.Password != "" {
+		query.Set("password", cfg.Password)
+	}
+
+	subprotocol := cfg.Driver
+	switch {
+	case isPostgres(cfg):
+		subprotocol = "postgresql"
+		if cfg.SSLMode != "" {
+			query.Set("sslmode", cfg.SSLMode)
+		}
+		if cfg.SSLRootCert != "" {
+			query.Set("sslrootcert", cfg.SSLRootCert)
+		}
+		if cfg.ReadOnly {
+			query.Set("readOnly", "true")
+		}
+	case jdbcSSLModes[cfg.Driver] != nil:
+		if mode, ok := jdbcSSLModes[cfg.Driver][cfg.SSLMode]; ok {
+			query.Set("sslMode", mode)
+		}
+	default:
+		return "", fmt.Errorf("the jdbc format does not support %s", cfg.Driver)
+	}
+
+	return fmt.Sprintf("jdbc:%s://%s/%s?%s", subprotocol,
+		net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), url.PathEscape(cfg.Database), query.Encode()), nil
+}
@@ -0,0 +1,371 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// importSources are the values of --from, in the order they are read.
+var importSources = []string{"pgpass", "service", "env", "dotenv"}
+
+// importItem is a candidate as shown in the preview, with the reason it is
+// not imported by default.
+type importItem struct {
+	utils.ImportCandidate
+	status string
+	skip   bool
+}
+
+func (item importItem) invalid() bool {
+	return strings.HasPrefix(item.status, "invalid")
+}
+
+func newImportCmd(store utils.ConfigStore) *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import configurations from .pgpass, pg_service.conf, PG* variables and .env files",
+		Long: `Read connections from ~/.pgpass, ~/.pg_service.conf, the PGHOST, PGUSER, ...
+environment variables and the DATABASE_URL variables of .env files, and save
+them as configurations.
+
+The connections found are listed first. Those already saved, or found twice,
+are marked as duplicates and skipped unless selected by number. Passwords
+missing from a service or the environment are looked up in .pgpass, like
+libpq does.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, _ := cmd.Flags().GetStringSlice("from")
+			yes, _ := cmd.Flags().GetBool("yes")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			candidates, err := importCandidates(cmd, from)
+			if err != nil {
+				utils.Log.Error("Failed to read connections", zap.Error(err))
+				os.Exit(1)
+			}
+			if len(candidates) == 0 {
+				fmt.Println("No connections found.")
+				return
+			}
+
+			existing, err := store.LoadConfigs()
+			if err != nil {
+				utils.Log.Error("Failed to load configurations", zap.Error(err))
+				os.Exit(1)
+			}
+			items := previewImport(candidates, existing)
+			writeImportPreview(os.Stdout, items)
+			if dryRun {
+				return
+			}
+
+			selected := defaultSelection(items)
+			if !yes {
+				selected, err = askSelection(os.Stdin, os.Stderr, items, selected)
+				if err != nil {
+					utils.Log.Error("Invalid selection", zap.Error(err))
+					os.Exit(1)
+				}
+			}
+			if len(selected) == 0 {
+				fmt.Println("Nothing imported.")
+				return
+			}
+			renameSelected(items, selected, existing)
+
+			newConfigs := make([]config.DBConfig, len(selected))
+			for i, index := range selected {
+				cfg := items[index].Config
+				cfg.ID = uuid.New()
+				cfg.Password, err = store.EncryptPassword(cfg.Password)
+				if err != nil {
+					utils.Log.Error("Failed to encrypt password", zap.Error(err))
+					os.Exit(1)
+				}
+				newConfigs[i] = cfg
+			}
+
+			err = store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+				return append(configs, newConfigs...), nil
+			})
+			if err != nil {
+				utils.Log.Error("Failed to save configurations", zap.Error(err))
+				os.Exit(1)
+			}
+			fmt.Printf("Imported %d configurations.\n", len(newConfigs))
+		},
+	}
+
+	importCmd.Flags().StringSlice("from", importSources, "Sources to read ("+strings.Join(importSources, ", ")+")")
+	importCmd.Flags().String("pgpass", pgFile("PGPASSFILE", ".pgpass"), "Password file")
+	importCmd.Flags().String("service-file", pgFile("PGSERVICEFILE", ".pg_service.conf"), "Connection service file")
+	importCmd.Flags().StringSlice("env-file", []string{".env"}, ".env files to read DATABASE_URL from")
+	importCmd.Flags().BoolP("yes", "y", false, "Import the new connections without asking")
+	importCmd.Flags().Bool("dry-run", false, "Only list the connections found")
+	return importCmd
+}
+
+// pgFile returns the file named by env, or name in the home directory.
+func pgFile(env, name string) string {
+	if file := os.Getenv(env); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(home, name)
+}
+
+// importCandidates reads the connections of the given sources. Missing
+// files are skipped.
+func importCandidates(cmd *cobra.Command, from []string) ([]utils.ImportCandidate, error) {
+	for _, source := range from {
+		if !slices.Contains(importSources, source) {
+			return nil, fmt.Errorf("unknown source %q, use %s", source, strings.Join(importSources, ", "))
+		}
+	}
+
+	// .pgpass is read for every source, since it provides their passwords
+	pgpassFile, _ := cmd.Flags().GetString("pgpass")
+	var entries []utils.PgpassEntry
+	f, err := os.Open(pgpassFile)
+	switch {
+	case err == nil:
+		entries, err = utils.ParsePgpass(f, pgpassFile)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	var candidates []utils.ImportCandidate
+	for _, source := range importSources {
+		if !slices.Contains(from, source) {
+			continue
+		}
+
+		var found []utils.ImportCandidate
+		switch source {
+		case "pgpass":
+			found = utils.PgpassCandidates(entries)
+		case "service":
+			serviceFile, _ := cmd.Flags().GetString("service-file")
+			found, err = utils.ImportFile(serviceFile, utils.ParsePgService)
+		case "env":
+			var candidate utils.ImportCandidate
+			var ok bool
+			candidate, ok, err = utils.PGEnvCandidate(os.LookupEnv)
+			if ok {
+				found = append(found, candidate)
+			}
+		case "dotenv":
+			envFiles, _ := cmd.Flags().GetStringSlice("env-file")
+			for _, envFile := range envFiles {
+				var fromFile []utils.ImportCandidate
+				fromFile, err = utils.ImportFile(envFile, utils.ParseDotEnv)
+				if err != nil {
+					break
+				}
+				found = append(found, fromFile...)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range found {
+			cfg := &found[i].Config
+			if cfg.Password == "" && cfg.PasswordRef == "" && (cfg.Driver == "postgres" || cfg.Driver == "cockroachdb") {
+				cfg.Password, _ = utils.PgpassPassword(entries, *cfg)
+			}
+		}
+		candidates = append(candidates, found...)
+	}
+	return candidates, nil
+}
+
+// previewImport marks the candidates that are invalid or duplicates of a
+// saved config or of an earlier candidate, and renames the others when their
+// name is taken.
+func previewImport(candidates []utils.ImportCandidate, existing []config.DBConfig) []importItem {
+	seen := make(map[string]string)
+	names := make(map[string]bool)
+	for _, cfg := range existing {
+		seen[utils.ConnectionKey(cfg)] = cfg.ConfigName
+		names[cfg.ConfigName] = true
+	}
+
+	items := make([]importItem, len(candidates))
+	for i, candidate := range candidates {
+		item := importItem{ImportCandidate: candidate, status: "new"}
+		key := utils.ConnectionKey(candidate.Config)
+
+		if err := utils.ValidateConfig(candidate.Config); err != nil {
+			item.status, item.skip = "invalid: "+err.Error(), true
+		} else if name, ok := seen[key]; ok {
+			item.status, item.skip = "duplicate of "+name, true
+		} else {
+			seen[key] = "#" + strconv.Itoa(i+1)
+		}
+
+		if !item.skip {
+			name := item.Config.ConfigName
+			for n := 2; names[item.Config.ConfigName]; n++ {
+				item.Config.ConfigName = fmt.Sprintf("%s-%d", name, n)
+			}
+			names[item.Config.ConfigName] = true
+		}
+
+		items[i] = item
+	}
+	return items
+}
+
+func writeImportPreview(w io.Writer, items []importItem) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tNAME\tDRIVER\tHOST\tPORT\tUSER\tDATABASE\tPASSWORD\tORIGIN\tSTATUS")
+	for i, item := range items {
+		cfg := item.Config
+		port := ""
+		if cfg.Port != 0 {
+			port = strconv.Itoa(cfg.Port)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1, cfg.ConfigName, cfg.Driver, cfg.Host, port, cfg.User, cfg.Database,
+			yesNo(cfg.Password != "" || cfg.PasswordRef != ""), item.Origin, item.status)
+	}
+	return tw.Flush()
+}
+
+// defaultSelection returns the indexes of the new, valid candidates.
+func defaultSelection(items []importItem) []int {
+	var selected []int
+	for i, item := range items {
+		if !item.skip {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// askSelection prompts for the candidates to import, an empty answer keeps
+// the default selection.
+func askSelection(in io.Reader, out io.Writer, items []importItem, selected []int) ([]int, error) {
+	numbers := make([]string, len(selected))
+	for i, index := range selected {
+		numbers[i] = strconv.Itoa(index + 1)
+	}
+	fmt.Fprintf(out, "Import which connections? Numbers such as 1,3-5, all or none [%s]: ", strings.Join(numbers, ","))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return selected, nil
+	}
+
+	selected, err = parseSelection(answer, len(items))
+	if err != nil {
+		return nil, err
+	}
+	// All is every connection that can be imported, duplicates included
+	if strings.EqualFold(answer, "all") {
+		selected = slices.DeleteFunc(selected, func(index int) bool { return items[index].invalid() })
+	}
+	for _, index := range selected {
+		if items[index].invalid() {
+			return nil, fmt.Errorf("#%d is %s", index+1, items[index].status)
+		}
+	}
+	return selected, nil
+}
+
+// renameSelected renames the selected duplicates whose name is taken, like
+// previewImport does for new candidates.
+func renameSelected(items []importItem, selected []int, existing []config.DBConfig) {
+	names := make(map[string]bool)
+	for _, cfg := range existing {
+		names[cfg.ConfigName] = true
+	}
+	for _, index := range selected {
+		if !items[index].skip {
+			names[items[index].Config.ConfigName] = true
+		}
+	}
+	for _, index := range selected {
+		cfg := &items[index].Config
+		if !items[index].skip {
+			continue
+		}
+		name := cfg.ConfigName
+		for n := 2; names[cfg.ConfigName]; n++ {
+			cfg.ConfigName = fmt.Sprintf("%s-%d", name, n)
+		}
+		names[cfg.ConfigName] = true
+	}
+}
+
+// parseSelection reads a list of 1-based numbers and ranges such as 1,3-5
+// into 0-based indexes below n.
+func parseSelection(answer string, n int) ([]int, error) {
+	switch strings.ToLower(answer) {
+	case "all":
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	case "none":
+		return nil, nil
+	}
+
+	var selected []int
+	chosen := make(map[int]bool)
+	for _, part := range strings.Split(answer, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			last = first
+		}
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		to, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		if from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("%q is not between 1 and %d", part, n)
+		}
+		for i := from - 1; i < to; i++ {
+			if !chosen[i] {
+				chosen[i] = true
+				selected = append(selected, i)
+			}
+		}
+	}
+	return selected, nil
+}
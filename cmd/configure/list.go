@@ -0,0 +1,215 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// listEntry is a config as printed by the list command. Passwords are never
+// printed, HasPassword tells whether one is stored.
+type listEntry struct {
+	ID          uuid.UUID `json:"id" yaml:"id"`
+	ConfigName  string    `json:"configName" yaml:"configName"`
+	Default     bool      `json:"default" yaml:"default"`
+	Driver      string    `json:"driver" yaml:"driver"`
+	Host        string    `json:"host" yaml:"host"`
+	Port        int       `json:"port" yaml:"port"`
+	User        string    `json:"user" yaml:"user"`
+	HasPassword bool      `json:"hasPassword" yaml:"hasPassword"`
+	PasswordRef string    `json:"passwordRef" yaml:"passwordRef"`
+	Database    string    `json:"database" yaml:"database"`
+
+	SSLMode     string `json:"sslMode" yaml:"sslMode"`
+	SSLRootCert string `json:"sslRootCert" yaml:"sslRootCert"`
+	SSLCert     string `json:"sslCert" yaml:"sslCert"`
+	SSLKey      string `json:"sslKey" yaml:"sslKey"`
+
+	Environment string `json:"environment" yaml:"environment"`
+	Color       string `json:"color" yaml:"color"`
+	ReadOnly    bool   `json:"readOnly" yaml:"readOnly"`
+
+	// Source is the project file declaring the config, empty for global ones.
+	Source string `json:"source" yaml:"source"`
+}
+
+func newListEntry(cfg config.DBConfig, defaultID uuid.UUID) listEntry {
+	return listEntry{
+		ID:          cfg.ID,
+		ConfigName:  cfg.ConfigName,
+		Default:     cfg.ID == defaultID,
+		Driver:      cfg.Driver,
+		Host:        cfg.Host,
+		Port:        cfg.Port,
+		User:        cfg.User,
+		HasPassword: cfg.Password != "",
+		PasswordRef: cfg.PasswordRef,
+		Database:    cfg.Database,
+
+		SSLMode:     cfg.SSLMode,
+		SSLRootCert: cfg.SSLRootCert,
+		SSLCert:     cfg.SSLCert,
+		SSLKey:      cfg.SSLKey,
+
+		Environment: cfg.Environment,
+		Color:       cfg.EnvironmentColor(),
+		ReadOnly:    cfg.ReadOnly,
+
+		Source: cfg.Source,
+	}
+}
+
+// listFormats maps the --output values to their writers.
+var listFormats = map[string]func(w io.Writer, entries []listEntry) error{
+	"table": writeListTable,
+	"json":  writeListJSON,
+	"yaml":  writeListYAML,
+	"env":   writeListEnv,
+}
+
+func newListCmd(store utils.ConfigStore) *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List database configurations",
+		Long: `Print all configurations without opening the interactive view. The default
+configuration is marked, passwords are never printed.
+
+Use --output json, yaml or env to process the list in scripts:
+
+  anydb configure list -o json | jq -r '.[] | select(.default) | .configName'`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			write, ok := listFormats[output]
+			if !ok {
+				utils.Log.Error("Unsupported output format", zap.String("output", output))
+				os.Exit(1)
+			}
+
+			configs, err := store.LoadConfigs()
+			if err != nil {
+				utils.Log.Error("Failed to load configurations", zap.Error(err))
+				os.Exit(1)
+			}
+
+			// Without a default no config is marked, errors such as a project
+			// default naming a missing config are reported.
+			defaultConfig, err := store.LoadDefaultConfig()
+			if err != nil {
+				utils.Log.Error("Failed to load default configuration", zap.Error(err))
+				os.Exit(1)
+			}
+
+			entries := make([]listEntry, len(configs))
+			for i, cfg := range configs {
+				entries[i] = newListEntry(cfg, defaultConfig.ID)
+			}
+
+			if err := write(os.Stdout, entries); err != nil {
+				utils.Log.Error("Failed to print configurations", zap.Error(err))
+				os.Exit(1)
+			}
+		},
+	}
+
+	listCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml, env)")
+	return listCmd
+}
+
+func writeListTable(w io.Writer, entries []listEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEFAULT\tNAME\tENV\tDRIVER\tHOST\tPORT\tUSER\tDATABASE\tSSL MODE\tREAD ONLY")
+	for _, e := range entries {
+		mark := ""
+		if e.Default {
+			mark = "*"
+		}
+		port := ""
+		if e.Port != 0 {
+			port = strconv.Itoa(e.Port)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", mark, e.ConfigName, e.Environment, e.Driver, e.Host, port, e.User, e.Database, e.SSLMode, yesNo(e.ReadOnly))
+	}
+	return tw.Flush()
+}
+
+func writeListJSON(w io.Writer, entries []listEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeListYAML(w io.Writer, entries []listEntry) error {
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeListEnv prints shell assignments prefixed with ANYDB_<NAME>_, which
+// can be sourced or passed to env. ANYDB_DEFAULT names the default config.
+func writeListEnv(w io.Writer, entries []listEntry) error {
+	for _, e := range entries {
+		prefix := "ANYDB_" + envName(e.ConfigName) + "_"
+		values := []struct{ key, value string }{
+			{"ID", e.ID.String()},
+			{"NAME", e.ConfigName},
+			{"DRIVER", e.Driver},
+			{"HOST", e.Host},
+			{"PORT", strconv.Itoa(e.Port)},
+			{"USER", e.User},
+			{"PASSWORD_REF", e.PasswordRef},
+			{"DATABASE", e.Database},
+			{"SSLMODE", e.SSLMode},
+			{"ENVIRONMENT", e.Environment},
+			{"READ_ONLY", strconv.FormatBool(e.ReadOnly)},
+		}
+		for _, v := range values {
+			if _, err := fmt.Fprintf(w, "%s%s=%s\n", prefix, v.key, shellQuote(v.value)); err != nil {
+				return err
+			}
+		}
+		if e.Default {
+			if _, err := fmt.Fprintf(w, "ANYDB_DEFAULT=%s\n", shellQuote(e.ConfigName)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// envName turns a config name into the upper case form usable in a
+// variable name.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -23,7 +24,15 @@ type item struct {
 
 func (i item) Title() string { return i.dbConfig.ConfigName }
 func (i item) Description() string {
-	return fmt.Sprintf("%s@%s:%s", i.dbConfig.Driver, i.dbConfig.Host, i.dbConfig.Database)
+	description := fmt.Sprintf("%s@%s:%s", i.dbConfig.Driver, i.dbConfig.Host, i.dbConfig.Database)
+	if dialect.IsFileBased(i.dbConfig.Driver) {
+		description = fmt.Sprintf("%s:%s", i.dbConfig.Driver, i.dbConfig.Database)
+	}
+	if i.dbConfig.Environment != "" {
+		env := lipgloss.NewStyle().Foreground(lipgloss.Color(i.dbConfig.EnvironmentColor())).Render("[" + i.dbConfig.Environment + "]")
+		description = env + " " + description
+	}
+	return description
 }
 func (i item) FilterValue() string { return i.dbConfig.ConfigName }
 
@@ -79,9 +88,9 @@ func (m model) View() string {
 	return docStyle.Render(m.list.View())
 }
 
-func newModel(title string) model {
-	items := make([]list.Item, len(config.Configs)) // Create slice with configuration items
-	for i, config := range config.Configs {
+func newModel(title string, configs []config.DBConfig) model {
+	items := make([]list.Item, len(configs)) // Create slice with configuration items
+	for i, config := range configs {
 		items[i] = item{dbConfig: config}
 	}
 
@@ -91,7 +100,7 @@ func newModel(title string) model {
 
 	m := model{
 		list:      list.New(items, delegate, 0, 0),
-		noConfigs: len(config.Configs) == 0,
+		noConfigs: len(configs) == 0,
 	}
 	m.list.Title = title
 	m.list.SetShowStatusBar(true)
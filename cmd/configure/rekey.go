@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newRekeyCmd(store utils.ConfigStore) *cobra.Command {
+	rekeyCmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "Rotate the master key protecting stored passwords",
+		Long: `Re-encrypts every stored password with a new master key.
+
+By default you are prompted for a new passphrase. With --key-file a random key
+is generated and written to the key file instead, which is then used to unlock
+without a prompt. ANYDB_MASTER_KEY, when set, must be updated afterwards.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			useKeyFile, _ := cmd.Flags().GetBool("key-file")
+			keyFile := store.MasterKeyFile()
+
+			// Unlock with the current key before the key file gets replaced.
+			if err := store.Unlock(); err != nil {
+				utils.Log.Error("Failed to unlock stored passwords", zap.Error(err))
+				os.Exit(1)
+			}
+
+			var passphrase string
+			var err error
+			if useKeyFile {
+				// Keep the new key on disk before any password depends on it.
+				passphrase, err = newRandomKey()
+				if err == nil {
+					err = os.WriteFile(keyFile+".new", []byte(passphrase+"\n"), 0600)
+				}
+			} else {
+				passphrase, err = utils.ReadNewPassphrase()
+			}
+			if err != nil {
+				utils.Log.Error("Failed to read new master key", zap.Error(err))
+				os.Exit(1)
+			}
+
+			if err := store.Rekey(passphrase); err != nil {
+				utils.Log.Error("Failed to rekey stored passwords", zap.Error(err))
+				os.Exit(1)
+			}
+
+			if useKeyFile {
+				err = os.Rename(keyFile+".new", keyFile)
+			} else {
+				// A stale key file would be picked up before the new passphrase.
+				err = os.Remove(keyFile)
+				if os.IsNotExist(err) {
+					err = nil
+				}
+			}
+			if err != nil {
+				utils.Log.Error("Failed to update master key file", zap.String("file", keyFile), zap.Error(err))
+				os.Exit(1)
+			}
+
+			fmt.Println("Master key rotated successfully.")
+		},
+	}
+
+	rekeyCmd.Flags().Bool("key-file", false, "Generate a random key and store it in the master key file")
+	return rekeyCmd
+}
+
+func newRandomKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
@@ -16,50 +16,55 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var removeCmd = &cobra.Command{
-	Use:   "remove",
-	Short: "Remove database configuration",
-	Run: func(cmd *cobra.Command, args []string) {
-
-		p := tea.NewProgram(newModel("Remove configuration"), tea.WithAltScreen())
-
-		finalModel, err := p.Run()
-		if err != nil {
-			fmt.Printf("Error running program: %v\n", err)
-			return
-		}
+func newRemoveCmd(store utils.ConfigStore) *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove database configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			configs, err := store.LoadConfigs()
+			if err != nil {
+				fmt.Printf("Failed to load configurations: %v\n", err)
+				return
+			}
 
-		if finalModel.(model).choice != nil {
-			choice := finalModel.(model).choice
-			fmt.Printf("Deleted configuration: %s\n", choice.ConfigName)
+			p := tea.NewProgram(newModel("Remove configuration", configs), tea.WithAltScreen())
 
-			err := utils.LoadDefaultConfig()
+			finalModel, err := p.Run()
 			if err != nil {
+				fmt.Printf("Error running program: %v\n", err)
 				return
 			}
 
-			if *choice == config.DefaultConfigData {
-				err = os.Truncate(config.DefaultConfigFile, 0)
-				if err != nil {
-					fmt.Printf("Error truncating file: %v\n", err)
+			if finalModel.(model).choice != nil {
+				choice := finalModel.(model).choice
+				if err := utils.CheckGlobalConfig(*choice); err != nil {
+					fmt.Printf("Configuration not removed: %v\n", err)
 					return
 				}
-			}
-
-			for index, value := range config.Configs {
-				if value == *choice {
-					config.Configs = append(config.Configs[:index], config.Configs[index+1:]...)
+				if err := utils.ConfirmProtected(*choice, "remove", os.Stdin, os.Stderr); err != nil {
+					fmt.Printf("Configuration not removed: %v\n", err)
+					return
 				}
-			}
+				fmt.Printf("Deleted configuration: %s\n", choice.ConfigName)
 
-			if err := utils.SaveConfigs(config.Configs, config.ConfigFile); err != nil {
-				fmt.Printf("Failed to save configuration: %v\n", err)
+				// Removing the default config clears the default as well.
+				err := store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+					for index, value := range configs {
+						if value.ID == choice.ID {
+							return append(configs[:index], configs[index+1:]...), nil
+						}
+					}
+					return configs, nil
+				})
+				if err != nil {
+					fmt.Printf("Failed to save configuration: %v\n", err)
+					return
+				}
 			}
-		}
-	},
-}
+		},
+	}
 
-func init() {
 	removeCmd.Flags().BoolP("help", "h", false, "help for add")
 	removeCmd.Flags().MarkHidden("help")
+	return removeCmd
 }
@@ -0,0 +1,133 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package configure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func newTestCmd(store utils.ConfigStore) *cobra.Command {
+	testCmd := &cobra.Command{
+		Use:   "test [name]",
+		Short: "Check the connection of database configurations",
+		Long: `Connect to a configuration and report the server version, ping latency,
+TLS status, current user and whether the session is read-only.
+
+Without arguments the configuration selected by --config, ANYDB_CONFIG or
+as default is tested. The command exits with a non-zero status if any
+connection fails.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			all, _ := cmd.Flags().GetBool("all")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			configs, err := targetConfigs(cmd, store, args, all)
+			if err != nil {
+				utils.Log.Error("Failed to select configurations", zap.Error(err))
+				os.Exit(1)
+			}
+
+			failed := 0
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tSTATUS\tLATENCY\tVERSION\tUSER\tTLS\tREAD ONLY\tERROR")
+			for _, cfg := range configs {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				report, err := utils.TestConnection(ctx, store, cfg)
+				cancel()
+
+				if err != nil {
+					failed++
+					fmt.Fprintf(tw, "%s\tfailed\t\t\t\t\t\t%v\n", cfg.ConfigName, err)
+					continue
+				}
+				fmt.Fprintf(tw, "%s\tok\t%s\t%s\t%s\t%s\t%s\t\n",
+					cfg.ConfigName,
+					report.Latency.Round(time.Microsecond),
+					report.Version,
+					report.User,
+					tlsStatus(cfg, report.TLS),
+					yesNo(report.ReadOnly),
+				)
+			}
+			tw.Flush()
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	testCmd.Flags().Bool("all", false, "Test every configuration")
+	testCmd.Flags().Duration("timeout", 10*time.Second, "Timeout of each connection test")
+	return testCmd
+}
+
+// targetConfigs picks the configs named by the arguments: all of them, the
+// ones with the given name or the active one.
+func targetConfigs(cmd *cobra.Command, store utils.ConfigStore, args []string, all bool) ([]config.DBConfig, error) {
+	if all && len(args) > 0 {
+		return nil, fmt.Errorf("pass either a configuration name or --all")
+	}
+
+	configs, err := store.LoadConfigs()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		if len(configs) == 0 {
+			return nil, fmt.Errorf("no configurations saved")
+		}
+		return configs, nil
+	}
+
+	if len(args) == 0 {
+		selector, _ := cmd.Flags().GetString("config")
+		cfg, err := utils.ActiveConfig(store, selector)
+		if err != nil {
+			return nil, err
+		}
+		return []config.DBConfig{cfg}, nil
+	}
+
+	var matched []config.DBConfig
+	for _, cfg := range configs {
+		if cfg.ConfigName == args[0] {
+			matched = append(matched, cfg)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%w: %s", utils.ErrConfigNotFound, args[0])
+	}
+	return matched, nil
+}
+
+func tlsStatus(cfg config.DBConfig, tls string) string {
+	switch {
+	case dialect.IsFileBased(cfg.Driver):
+		return "n/a"
+	case tls == "":
+		return "off"
+	}
+	return tls
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -12,37 +12,46 @@ import (
 	"github.com/AnyoneClown/anydb/cmd/backup"
 	"github.com/AnyoneClown/anydb/cmd/configure"
 	"github.com/AnyoneClown/anydb/cmd/table"
-	"github.com/AnyoneClown/anydb/config"
 	"github.com/AnyoneClown/anydb/utils"
 	"github.com/spf13/cobra"
-	"go.uber.org/zap"
 )
 
+// store is opened in the home directory once the flags are parsed, and
+// shared by every command.
+var store = &utils.YAMLStore{}
+
 var rootCmd = &cobra.Command{
 	Use:   "anydb",
 	Short: "CLI tool for managing your DB. Get table content, backup your DB!",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		homeFlag, _ := cmd.Flags().GetString("home")
+		home, err := utils.ResolveHome(homeFlag)
+		if err != nil {
+			return err
+		}
+
+		// The home directory must exist before the logger opens its file there.
+		if err := store.Open(home); err != nil {
+			return err
+		}
+		utils.InitLogger(home) // Register Zap logger
+		return nil
+	},
 }
 
 func Execute() {
 	err := rootCmd.Execute()
+	utils.Log.Sync()
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	utils.InitLogger() // Register Zap logger
-	defer utils.Log.Sync()
-
-	utils.CreateFileAndDir()
-
-	var err error
-	config.Configs, err = utils.LoadConfigs(config.ConfigFile)
-	if err != nil {
-		utils.Log.Error("File doesn't exist, creating configuration file", zap.Error(err))
-	}
+	rootCmd.PersistentFlags().String("home", "", "Directory holding the anydb configuration (default $"+utils.HomeEnv+" or ~/.anydb)")
+	rootCmd.PersistentFlags().StringP("config", "c", "", "Name or ID of the configuration to use instead of the default (default $"+utils.ConfigEnv+")")
 
-	rootCmd.AddCommand(configure.ConfigureCmd)
-	rootCmd.AddCommand(table.TableCmd)
+	rootCmd.AddCommand(configure.NewConfigureCmd(store))
+	rootCmd.AddCommand(table.NewTableCmd(store))
 	rootCmd.AddCommand(backup.BackupCmd)
 }
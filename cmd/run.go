@@ -14,18 +14,23 @@ import (
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:   "run",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+	Short: "Serve the web interface managing the configurations",
+	Long: `Serve the web interface and the /api endpoints managing the configurations.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+The server has no authentication, so it only listens on the loopback
+interface unless --listen names another address. Without --listen, API
+requests must also be sent to localhost or a loopback address, so other
+sites cannot reach the API through DNS rebinding.
+
+Password references set over the API can only read the environment
+variables allowed with anydb configure allow-env.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		web.Web()
+		listen, _ := cmd.Flags().GetString("listen")
+		web.Web(store, listen, !cmd.Flags().Changed("listen"))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().String("listen", "127.0.0.1:8080", "Address to listen on, host:port")
 }
@@ -0,0 +1,188 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package table
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/query"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+var (
+	fieldStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	selectedFieldStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	typeStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	nullStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+)
+
+// detail shows every field of a row on its own line with its full value and
+// type. JSON values are pretty printed and can be folded onto one line.
+type detail struct {
+	columns []query.Column
+	row     query.Row
+	format  query.Format
+	cursor  int
+	// folded holds the JSON fields shown on one line.
+	folded   map[int]bool
+	viewport viewport.Model
+	status   string
+}
+
+func newDetail(rs query.ResultSet, row query.Row, format query.Format, width, height int) detail {
+	d := detail{
+		columns:  rs.Columns,
+		row:      row,
+		format:   format,
+		folded:   make(map[int]bool),
+		viewport: viewport.New(0, 0),
+	}
+	d.setSize(width, height)
+	return d
+}
+
+// setSize fits the detail, with its border, in width and height.
+func (d *detail) setSize(width, height int) {
+	d.viewport.Width = max(width-2, 20)
+	d.viewport.Height = max(height-2, 3)
+	d.render()
+}
+
+func (d detail) Update(msg tea.KeyMsg) (detail, tea.Cmd) {
+	d.status = ""
+	switch msg.String() {
+	case "up", "k":
+		d.cursor = max(d.cursor-1, 0)
+	case "down", "j":
+		d.cursor = min(d.cursor+1, len(d.columns)-1)
+	case "home", "g":
+		d.cursor = 0
+	case "end", "G":
+		d.cursor = len(d.columns) - 1
+	case "pgup":
+		d.viewport.ViewUp()
+		return d, nil
+	case "pgdown":
+		d.viewport.ViewDown()
+		return d, nil
+	case "enter", " ":
+		if d.row[d.cursor] != nil && d.columns[d.cursor].Kind() == query.KindJSON {
+			d.folded[d.cursor] = !d.folded[d.cursor]
+		}
+	case "c", "y":
+		d.status = d.copyValue(d.cursor)
+	}
+	d.render()
+	return d, nil
+}
+
+func (d detail) View() string {
+	return baseStyle.Render(d.viewport.View())
+}
+
+// statusBar reports the outcome of the last copy, or the keys of the detail.
+func (d detail) statusBar() string {
+	if d.status != "" {
+		return statusStyle.Render(d.status)
+	}
+	return statusStyle.Render(fmt.Sprintf("field %d of %d · enter/space fold json · c copy value · pgup/pgdown scroll · tab back",
+		d.cursor+1, len(d.columns)))
+}
+
+// value returns the full text of field i.
+func (d detail) value(i int) string {
+	format := d.format
+	format.PrettyJSON = !d.folded[i]
+	return format.Value(d.columns[i], d.row[i])
+}
+
+// copyValue copies the value of field i to the system clipboard. Without
+// one, as over SSH, it is sent to the terminal as an OSC 52 sequence, which
+// most terminals copy to theirs.
+func (d detail) copyValue(i int) string {
+	name := d.columns[i].Name
+	if d.row[i] == nil {
+		return name + " is NULL, nothing copied"
+	}
+	if err := clipboard.WriteAll(d.value(i)); err != nil {
+		termenv.Copy(d.value(i))
+	}
+	return "copied " + name
+}
+
+// render lays out the fields and scrolls the viewport to the selected one.
+func (d *detail) render() {
+	nameWidth, typeWidth := 0, 0
+	for _, column := range d.columns {
+		nameWidth = max(nameWidth, lipgloss.Width(column.Name))
+		typeWidth = max(typeWidth, lipgloss.Width(columnType(column)))
+	}
+	nameWidth = min(nameWidth, 30)
+	typeWidth = min(typeWidth, 24)
+	valueWidth := max(d.viewport.Width-nameWidth-typeWidth-6, 10)
+
+	var lines []string
+	var top, bottom int
+	for i, column := range d.columns {
+		marker, style := "  ", fieldStyle
+		if i == d.cursor {
+			marker, style = "> ", selectedFieldStyle
+		}
+
+		value := d.value(i)
+		switch {
+		case d.row[i] == nil:
+			value = nullStyle.Render("NULL")
+		case column.Kind() == query.KindJSON && d.folded[i]:
+			value = "▸ " + value
+		case column.Kind() == query.KindJSON:
+			value = "▾ " + strings.ReplaceAll(value, "\n", "\n  ")
+		}
+
+		field := lipgloss.JoinHorizontal(lipgloss.Top,
+			marker,
+			style.Width(nameWidth).MaxWidth(nameWidth).Render(column.Name), "  ",
+			typeStyle.Width(typeWidth).MaxWidth(typeWidth).Render(columnType(column)), "  ",
+			// Values wrap at the width rather than between words, so long
+			// strings stay next to their field and keep their spaces
+			lipgloss.NewStyle().Width(valueWidth).Render(ansi.Hardwrap(value, valueWidth, true)),
+		)
+		if i == d.cursor {
+			top = len(lines)
+		}
+		lines = append(lines, strings.Split(field, "\n")...)
+		if i == d.cursor {
+			bottom = len(lines)
+		}
+	}
+	d.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// Keep the selected field in view, its first line when it is taller
+	switch {
+	case top < d.viewport.YOffset:
+		d.viewport.SetYOffset(top)
+	case bottom > d.viewport.YOffset+d.viewport.Height:
+		d.viewport.SetYOffset(min(bottom-d.viewport.Height, top))
+	}
+}
+
+// columnType describes the type of column, marking those that cannot be NULL.
+func columnType(column query.Column) string {
+	if column.Type == "" {
+		return "unknown"
+	}
+	if !column.Nullable {
+		return column.Type + " not null"
+	}
+	return column.Type
+}
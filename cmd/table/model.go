@@ -8,8 +8,12 @@ package table
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/query"
 	"github.com/AnyoneClown/anydb/utils"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/table"
@@ -25,17 +29,49 @@ var baseStyle = lipgloss.NewStyle().
 
 var docStyle = lipgloss.NewStyle().Margin(1, 2)
 
+var (
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
 type model struct {
-	db          *sqlx.DB
-	list        list.Model
-	table       table.Model
-	tableChosen bool
-	chosenTable string
-	limit       int
-	width       int
-	height      int
+	db      *sqlx.DB
+	dialect dialect.Dialect
+	cfg     config.DBConfig
+	// schemas lists the schemas of the database when there are several to
+	// choose from, list the tables of the chosen one.
+	schemas      list.Model
+	hasSchemas   bool
+	schemaChosen bool
+	chosenSchema string
+	list         list.Model
+	table        table.Model
+	tableChosen  bool
+	chosenTable  string
+	// detail shows the row chosen in the table.
+	detail    detail
+	rowChosen bool
+	limit     int
+	format    query.Format
+	width     int
+	height    int
+
+	// pager reads the chosen table, page holds the rows shown and total is
+	// the row count of the table when it was listed.
+	pager *utils.Pager
+	page  utils.Page
+	total int
+	err   error
 }
 
+type SchemaItem struct {
+	SchemaName string
+}
+
+func (i SchemaItem) Title() string       { return i.SchemaName }
+func (i SchemaItem) Description() string { return "" }
+func (i SchemaItem) FilterValue() string { return i.SchemaName }
+
 type Item struct {
 	TableName string
 	RowsCount int
@@ -53,100 +89,272 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		h, v := docStyle.GetFrameSize()
 		m.width = msg.Width - h
 		m.height = msg.Height - v
-		m.list.SetSize(m.width, m.height)
+		if m.hasSchemas {
+			m.schemas.SetSize(m.width, m.height)
+		}
+		if m.schemaChosen {
+			m.list.SetSize(m.width, m.height)
+		}
+		if m.rowChosen {
+			m.detail.setSize(m.width, m.height-3)
+		}
 
 	case tea.KeyMsg:
+		if m.rowChosen {
+			switch msg.String() {
+			case "tab", "esc":
+				m.rowChosen = false
+				return m, nil
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.detail, cmd = m.detail.Update(msg)
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "tab":
-			if m.tableChosen {
+			// Go back up a level, from the rows to the tables to the schemas
+			switch {
+			case m.tableChosen:
 				m.tableChosen = false
 				m.list.SetSize(m.width, m.height)
+			case m.schemaChosen && m.hasSchemas:
+				m.schemaChosen = false
+				m.schemas.SetSize(m.width, m.height)
 			}
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		case "enter":
+			if m.tableChosen {
+				if len(m.page.Rows) > 0 {
+					m.detail = newDetail(m.page.ResultSet, m.page.Rows[m.table.Cursor()], m.format, m.width, m.height-3)
+					m.rowChosen = true
+				}
+				return m, nil
+			}
+			if !m.schemaChosen {
+				// Nothing is selected when the list is empty or filtered to nothing
+				item, ok := m.schemas.SelectedItem().(SchemaItem)
+				if !ok {
+					return m, nil
+				}
+				if err := m.chooseSchema(item.SchemaName); err != nil {
+					utils.Log.Error("Failed to list tables", zap.Error(err))
+					return m, tea.Quit
+				}
+				return m, nil
+			}
 			if !m.tableChosen {
-				m.chosenTable = m.list.SelectedItem().(Item).TableName
+				item, ok := m.list.SelectedItem().(Item)
+				if !ok {
+					return m, nil
+				}
+				m.chosenTable = item.TableName
+				m.total = item.RowsCount
 				m.tableChosen = true
+				m.err = nil
 				var err error
-				m.table, err = initializeTableData(m.db, m.chosenTable, m.limit)
+				m.table, m.pager, m.page, err = initializeTableData(m.db, m.dialect, m.chosenSchema, m.chosenTable, m.limit, m.format)
 				if err != nil {
 					utils.Log.Error("Failed to initialize table data", zap.Error(err))
 					return m, tea.Quit
 				}
 			}
+		case "pgdown", "pgup", "home", "end":
+			if m.tableChosen {
+				m.turnPage(msg.String())
+				return m, nil
+			}
+		case "down", "j":
+			// Scrolling past the last row loads the next page below it
+			if m.tableChosen && m.table.Cursor() == len(m.page.Rows)-1 {
+				next, err := m.pager.Next(m.page)
+				m.err = err
+				if err == nil && len(next.Rows) > 0 {
+					m.page = m.page.Extend(next)
+					m.table.SetRows(tableRows(m.page, m.format))
+				}
+			}
 		}
 	}
 
 	var cmd tea.Cmd
-	if m.tableChosen {
+	switch {
+	case m.tableChosen:
 		m.table, cmd = m.table.Update(msg)
-	} else {
+	case m.schemaChosen:
 		m.list, cmd = m.list.Update(msg)
+	default:
+		m.schemas, cmd = m.schemas.Update(msg)
 	}
 	return m, cmd
 }
 
 func (m model) View() string {
-	if m.tableChosen {
-		return baseStyle.Render(m.table.View()) + "\n  " + m.table.HelpView() + "\n"
+	switch {
+	case m.rowChosen:
+		row := fmt.Sprintf("row %d", m.page.Offset+m.table.Cursor()+1)
+		return headerStyle(m.cfg).Render(header(m.cfg, m.chosenSchema+"."+m.chosenTable, row)) + "\n" +
+			m.detail.View() + "\n  " + m.detail.statusBar() + "\n"
+	case m.tableChosen:
+		return headerStyle(m.cfg).Render(header(m.cfg, m.chosenSchema+"."+m.chosenTable)) + "\n" +
+			baseStyle.Render(m.table.View()) + "\n  " + m.statusBar() + "\n  " + m.table.HelpView() + "\n"
+	case m.schemaChosen:
+		return docStyle.Render(m.list.View())
+	default:
+		return docStyle.Render(m.schemas.View())
 	}
-	return docStyle.Render(m.list.View())
 }
 
-func initializeTableList(db *sqlx.DB) (list.Model, error) {
-	tables, err := utils.GetTables(db)
+// chooseSchema lists the tables of schema.
+func (m *model) chooseSchema(schema string) error {
+	l, err := initializeTableList(m.db, m.dialect, m.cfg, schema)
 	if err != nil {
-		utils.Log.Error("Failed retrieve tables", zap.Error(err))
-		return list.Model{}, err
+		return err
 	}
+	m.list = l
+	m.list.SetSize(m.width, m.height)
+	m.chosenSchema = schema
+	m.schemaChosen = true
+	return nil
+}
 
-	items := make([]list.Item, len(tables))
-	for i, table := range tables {
-		items[i] = Item{TableName: table.TableName, RowsCount: table.RowsCount}
+// turnPage replaces the rows shown with the next, previous, first or last page.
+func (m *model) turnPage(key string) {
+	var page utils.Page
+	var err error
+	switch key {
+	case "pgdown":
+		page, err = m.pager.Next(m.page)
+	case "pgup":
+		page, err = m.pager.Prev(m.page)
+	case "home":
+		page, err = m.pager.First()
+	case "end":
+		page, err = m.pager.Last(m.total)
+	}
+
+	m.err = err
+	if err != nil || len(page.Rows) == 0 {
+		return
+	}
+	m.page = page
+	m.table.SetRows(tableRows(m.page, m.format))
+	if key == "end" {
+		m.table.GotoBottom()
+	} else {
+		m.table.GotoTop()
+	}
+}
+
+// statusBar shows the position of the selected row in the table.
+func (m model) statusBar() string {
+	if m.err != nil {
+		return errorStyle.Render(m.err.Error())
+	}
+
+	limit := m.pager.Limit()
+	row := m.page.Offset + m.table.Cursor() + 1
+	total := max(m.total, m.page.Offset+len(m.page.Rows))
+	pages := max((total+limit-1)/limit, 1)
+
+	if len(m.page.Rows) == 0 {
+		return statusStyle.Render("no rows")
+	}
+	return statusStyle.Render(fmt.Sprintf("row %d of %d · page %d of %d · %s · enter details · pgup/pgdown page · home/end first/last",
+		row, total, (row-1)/limit+1, pages, m.pager.Order()))
+}
+
+// header names the config, its environment and whether it is read-only,
+// followed by the given parts.
+func header(cfg config.DBConfig, parts ...string) string {
+	label := []string{cfg.ConfigName}
+	if cfg.Environment != "" {
+		label = append(label, cfg.Environment)
+	}
+	if cfg.ReadOnly {
+		label = append(label, "read-only")
 	}
+	return strings.Join(append(label, parts...), " · ")
+}
 
+// headerStyle colors the header in the color of the config environment.
+func headerStyle(cfg config.DBConfig) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color(cfg.EnvironmentColor())).
+		Foreground(lipgloss.Color("230")).
+		Padding(0, 1)
+}
+
+// newListDelegate returns the delegate rendering the items of the schema and
+// table lists.
+func newListDelegate() list.DefaultDelegate {
 	delegate := list.NewDefaultDelegate()
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(lipgloss.Color("170")).BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("170"))
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(lipgloss.Color("241"))
+	return delegate
+}
+
+func initializeSchemaList(schemas []string, cfg config.DBConfig) list.Model {
+	items := make([]list.Item, len(schemas))
+	for i, schema := range schemas {
+		items[i] = SchemaItem{SchemaName: schema}
+	}
+
+	delegate := newListDelegate()
+	delegate.ShowDescription = false
 
 	resultList := list.New(items, delegate, 0, 0)
-	resultList.Title = "Choose Database"
+	resultList.Title = header(cfg, "Choose Schema")
 	resultList.SetShowStatusBar(true)
 	resultList.SetFilteringEnabled(true)
-	resultList.Styles.Title = lipgloss.NewStyle().
-		Background(lipgloss.Color("62")).
-		Foreground(lipgloss.Color("230")).
-		Padding(0, 1)
+	resultList.Styles.Title = headerStyle(cfg)
+	return resultList
+}
+
+func initializeTableList(db *sqlx.DB, d dialect.Dialect, cfg config.DBConfig, schema string) (list.Model, error) {
+	tables, err := utils.GetTables(db, d, schema)
+	if err != nil {
+		utils.Log.Error("Failed retrieve tables", zap.Error(err))
+		return list.Model{}, err
+	}
+
+	items := make([]list.Item, len(tables))
+	for i, table := range tables {
+		items[i] = Item{TableName: table.TableName, RowsCount: table.RowsCount}
+	}
+
+	resultList := list.New(items, newListDelegate(), 0, 0)
+	resultList.Title = header(cfg, schema, "Choose Table")
+	resultList.SetShowStatusBar(true)
+	resultList.SetFilteringEnabled(true)
+	resultList.Styles.Title = headerStyle(cfg)
 
 	return resultList, nil
 }
 
-func initializeTableData(db *sqlx.DB, tableName string, limit int) (table.Model, error) {
-	columns, err := utils.GetTableColumns(db, tableName)
+func initializeTableData(db *sqlx.DB, d dialect.Dialect, schema, tableName string, limit int, format query.Format) (table.Model, *utils.Pager, utils.Page, error) {
+	pager, err := utils.NewPager(db, d, schema, tableName, limit)
 	if err != nil {
-		return table.Model{}, err
+		return table.Model{}, nil, utils.Page{}, err
 	}
-
-	records, err := utils.GetLastRecords(db, tableName, limit)
+	page, err := pager.First()
 	if err != nil {
-		return table.Model{}, err
+		return table.Model{}, nil, utils.Page{}, err
 	}
 
-	rows := make([]table.Row, len(records))
-	for i, record := range records {
-		row := make(table.Row, len(columns))
-		for j, column := range columns {
-			row[j] = fmt.Sprintf("%v", record[strings.ToLower(column.Title)])
-		}
-		rows[i] = row
+	columns := make([]table.Column, len(page.Columns))
+	for i, column := range page.Columns {
+		columns[i] = table.Column{Title: column.Name, Width: 20}
 	}
 
 	t := table.New(
 		table.WithColumns(columns),
-		table.WithRows(rows),
+		table.WithRows(tableRows(page, format)),
 		table.WithFocused(true),
-		table.WithHeight(7),
+		table.WithHeight(limit+2),
 	)
 
 	s := table.DefaultStyles()
@@ -161,24 +369,78 @@ func initializeTableData(db *sqlx.DB, tableName string, limit int) (table.Model,
 		Bold(false)
 	t.SetStyles(s)
 
-	return t, nil
+	return t, pager, page, nil
+}
+
+// singleLine keeps multiline values, such as text and JSON, on the line of
+// their row.
+var singleLine = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// tableRows formats the rows of page.
+func tableRows(page utils.Page, format query.Format) []table.Row {
+	rows := make([]table.Row, len(page.Rows))
+	for i, row := range page.Rows {
+		values := format.Row(page.ResultSet, row)
+		for j, value := range values {
+			values[j] = singleLine.Replace(value)
+		}
+		rows[i] = values
+	}
+	return rows
 }
 
-func NewModel(db *sqlx.DB, limit int) (model, error) {
-	l, err := initializeTableList(db)
+// NewModel starts the browser at the schemas of the database, or at the
+// tables of schema when given or when the database has a single schema.
+// Without schema, MySQL starts at the database of cfg, the others are
+// listed when going back with tab.
+func NewModel(db *sqlx.DB, d dialect.Dialect, cfg config.DBConfig, schema string, limit int, format query.Format) (model, error) {
+	schemas, err := utils.GetSchemas(db, d)
 	if err != nil {
 		return model{}, err
 	}
 
 	m := model{
 		db:          db,
-		list:        l,
+		dialect:     d,
+		cfg:         cfg,
 		tableChosen: false,
 		chosenTable: "",
 		limit:       limit,
+		format:      format,
 		width:       0,
 		height:      0,
 	}
 
+	if len(schemas) > 1 {
+		m.schemas = initializeSchemaList(schemas, cfg)
+		m.hasSchemas = true
+	}
+
+	var defaultSchema string
+	if ds, ok := d.(dialect.DefaultSchemaer); ok {
+		defaultSchema = ds.DefaultSchema(cfg)
+	}
+
+	switch {
+	case schema != "":
+		if !slices.Contains(schemas, schema) {
+			return model{}, fmt.Errorf("schema %q not found, the database has %s", schema, strings.Join(schemas, ", "))
+		}
+	case defaultSchema != "" && slices.Contains(schemas, defaultSchema):
+		schema = defaultSchema
+	case len(schemas) == 0:
+		return model{}, fmt.Errorf("the database has no schemas")
+	case len(schemas) == 1:
+		schema = schemas[0]
+	default:
+		return m, nil
+	}
+
+	if m.hasSchemas {
+		m.schemas.Select(slices.Index(schemas, schema))
+	}
+	if err := m.chooseSchema(schema); err != nil {
+		return model{}, err
+	}
 	return m, nil
 }
@@ -7,49 +7,77 @@ master my life.
 package table
 
 import (
-	"fmt"
+	"strings"
 
+	"github.com/AnyoneClown/anydb/query"
 	"github.com/AnyoneClown/anydb/utils"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/jmoiron/sqlx"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
-
-	_ "github.com/lib/pq"
 )
 
-var TableCmd = &cobra.Command{
-	Use:   "table",
-	Short: "Display tables and their contents",
-	Run: func(cmd *cobra.Command, args []string) {
-		limit, _ := cmd.Flags().GetInt("rows")
-
-		dsn, err := utils.GetDBString()
-		if err != nil {
-			utils.Log.Error("Error getting database string:", zap.Error(err))
-			return
-		}
-
-		db, err := sqlx.Connect("postgres", dsn)
-		if err != nil {
-			utils.Log.Error("Error connecting to database:", zap.Error(err))
-			fmt.Printf(dsn)
-			return
-		}
-		defer db.Close()
-
-		model, err := NewModel(db, limit)
-		if err != nil {
-			utils.Log.Error("Error initializing model:", zap.Error(err))
-			return
-		}
-		if _, err := tea.NewProgram(model).Run(); err != nil {
-			utils.Log.Error("Error running program:", zap.Error(err))
-			return
-		}
-	},
-}
+// nullText marks NULL values apart from text reading NULL. Cells are
+// truncated by width, which leaves no room for styling them.
+const nullText = "∅"
+
+// NewTableCmd builds the table command, connecting to the active config of store.
+func NewTableCmd(store utils.ConfigStore) *cobra.Command {
+	tableCmd := &cobra.Command{
+		Use:   "table",
+		Short: "Display tables and their contents",
+		Long: `Browse the schemas of the database, the tables of a schema and the rows
+of a table. Press enter to open a schema, a table or a row and tab to go
+back. A row opens with every field on its own line, JSON pretty printed, and
+c copies the selected value.
+
+Databases with a single schema, such as most SQLite files, open at their
+tables, and MySQL opens at the database of the config. With --schema the
+browser opens at the tables of that schema.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("rows")
+			schema, _ := cmd.Flags().GetString("schema")
+			utc, _ := cmd.Flags().GetBool("utc")
+			binary, _ := cmd.Flags().GetString("binary")
+
+			format := query.DefaultFormat
+			format.Null = nullText
+			format.UTC = utc
+			var err error
+			format.Binary, err = query.ParseBinaryEncoding(binary)
+			if err != nil {
+				utils.Log.Error("Error reading binary encoding:", zap.Error(err))
+				return
+			}
+
+			selector, _ := cmd.Flags().GetString("config")
+			cfg, err := utils.ActiveConfig(store, selector)
+			if err != nil {
+				utils.Log.Error("Error selecting configuration:", zap.Error(err))
+				return
+			}
+
+			db, d, err := utils.Connect(store, cfg)
+			if err != nil {
+				utils.Log.Error("Error connecting to database:", zap.Error(err))
+				return
+			}
+			defer db.Close()
+
+			model, err := NewModel(db, d, cfg, schema, limit, format)
+			if err != nil {
+				utils.Log.Error("Error initializing model:", zap.Error(err))
+				return
+			}
+			if _, err := tea.NewProgram(model).Run(); err != nil {
+				utils.Log.Error("Error running program:", zap.Error(err))
+				return
+			}
+		},
+	}
 
-func init() {
-	TableCmd.Flags().IntP("rows", "r", 5, "Number of rows to display")
+	tableCmd.Flags().IntP("rows", "r", 5, "Number of rows per page")
+	tableCmd.Flags().StringP("schema", "s", "", "Schema to open, or database for MySQL and attached database for SQLite")
+	tableCmd.Flags().Bool("utc", false, "Show timestamps in UTC instead of local time")
+	tableCmd.Flags().String("binary", query.DefaultFormat.Binary, "Encoding of binary values ("+strings.Join(query.BinaryEncodings, ", ")+")")
+	return tableCmd
 }
@@ -6,25 +6,118 @@ master my life.
 */
 package config
 
-import "github.com/google/uuid"
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ConfigVersion is the current layout of the configuration file. Version 1
+// files were a bare list of configs with the port stored as a string, and
+// up to version 2 the default config was copied into a separate file.
+const ConfigVersion = 3
+
+// ConfigFileData is the on-disk layout of the configuration file.
+type ConfigFileData struct {
+	Version    int               `yaml:"version"`
+	Encryption *EncryptionHeader `yaml:"encryption,omitempty"`
+	// Default is the ID of the config used when none is selected.
+	Default *uuid.UUID `yaml:"default,omitempty"`
+	Configs []DBConfig `yaml:"configs"`
+	// AllowedEnv names the environment variables project files and the
+	// web API may reference as passwords.
+	AllowedEnv []string `yaml:"allowedEnv,omitempty"`
+}
+
+// ProjectFileData is the layout of the project file, .anydb.yaml, which is
+// found by walking up from the working directory. Default names the config
+// used in the project, a project config or a global one.
+type ProjectFileData struct {
+	Default string          `yaml:"default,omitempty"`
+	Configs []ProjectConfig `yaml:"configs"`
+}
+
+// ProjectConfig is either a config declared by the project or, with Ref
+// set, a reference to a global config by name.
+type ProjectConfig struct {
+	Ref      string `yaml:"ref,omitempty"`
+	DBConfig `yaml:",inline"`
+}
+
+// EncryptionHeader describes how the stored passwords are encrypted. Check
+// holds a known value encrypted with the master key, so a wrong passphrase
+// is detected before any password is decrypted.
+type EncryptionHeader struct {
+	KDF   string `yaml:"kdf"`
+	Salt  string `yaml:"salt"`
+	Check string `yaml:"check"`
+}
 
 type DBConfig struct {
 	ID         uuid.UUID `yaml:"id"`
 	ConfigName string    `yaml:"configName"`
 	Driver     string    `yaml:"driver"`
 	Host       string    `yaml:"host"`
-	Port       string    `yaml:"port"`
+	Port       int       `yaml:"port"`
 	User       string    `yaml:"user"`
 	Password   string    `yaml:"password"`
 	Database   string    `yaml:"database"`
+
+	// PasswordRef points at a secret resolved when connecting, such as
+	// env:PGPASSWORD, file:/run/secrets/db or cmd:pass show db. It takes
+	// the place of Password, which is then left empty.
+	PasswordRef string `yaml:"passwordRef,omitempty"`
+
+	// TLS settings, empty values fall back to the driver defaults.
+	SSLMode     string `yaml:"sslMode,omitempty"`
+	SSLRootCert string `yaml:"sslRootCert,omitempty"`
+	SSLCert     string `yaml:"sslCert,omitempty"`
+	SSLKey      string `yaml:"sslKey,omitempty"`
+
+	// Environment labels the config, such as dev, staging or prod, and Color
+	// is the hex color it is shown in. ReadOnly configs only open read-only
+	// sessions.
+	Environment string `yaml:"environment,omitempty"`
+	Color       string `yaml:"color,omitempty"`
+	ReadOnly    bool   `yaml:"readOnly,omitempty"`
+
+	// Source is the project file declaring the config, empty for configs of
+	// the global configuration file. It is never written.
+	Source string `yaml:"-"`
 }
 
-var Configs []DBConfig
-var ConfigFile string
-var DefaultConfigFile string
-var DefaultConfigData DBConfig
+// environmentColors are used for configs without a color of their own.
+var environmentColors = map[string]string{
+	"dev":         "#16a34a",
+	"development": "#16a34a",
+	"staging":     "#d97706",
+	"prod":        "#dc2626",
+	"production":  "#dc2626",
+}
+
+// EnvironmentColor returns the color the config is shown in, falling back
+// to the color of its environment.
+func (c DBConfig) EnvironmentColor() string {
+	if c.Color != "" {
+		return c.Color
+	}
+	if color, ok := environmentColors[strings.ToLower(c.Environment)]; ok {
+		return color
+	}
+	return "#6b7280"
+}
+
+// Protected reports whether changes to the config have to be confirmed by
+// typing its database name, which is the case for read-only and production
+// configs.
+func (c DBConfig) Protected() bool {
+	env := strings.ToLower(c.Environment)
+	return c.ReadOnly || env == "prod" || env == "production"
+}
 
-var SupportedDrivers = []string{
-	"postgres",
-	"cockroachdb",
+var SSLModes = []string{
+	"disable",
+	"require",
+	"verify-ca",
+	"verify-full",
 }
@@ -0,0 +1,22 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package dialect
+
+// CockroachDB speaks the Postgres wire protocol and exposes the same
+// information_schema, so it only differs from postgres in its TLS default
+// and port.
+type cockroachdb struct {
+	postgres
+}
+
+func init() {
+	Register("cockroachdb", cockroachdb{postgres{sslMode: "verify-full", port: 26257}})
+}
+
+// RowID is the hidden key CockroachDB adds to tables without a primary key,
+// it has no ctid.
+func (cockroachdb) RowID() string { return "rowid" }
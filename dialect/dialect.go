@@ -0,0 +1,166 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package dialect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// Column describes a single table column as reported by the database.
+type Column struct {
+	Name     string `db:"name"`
+	Type     string `db:"type"`
+	Nullable bool   `db:"nullable"`
+}
+
+// ServerInfo describes the server and session behind a connection.
+type ServerInfo struct {
+	Version string
+	User    string
+	// TLS names the protocol or cipher of an encrypted connection, it is
+	// empty for plaintext and local connections.
+	TLS      string
+	ReadOnly bool
+}
+
+// Dialect is implemented by every supported database backend.
+type Dialect interface {
+	// DriverName is the database/sql driver used to open connections.
+	DriverName() string
+	// DSN builds the connection string for the given configuration. Every
+	// session opened for a read-only configuration must be read-only.
+	DSN(cfg config.DBConfig) (string, error)
+	// ListSchemas returns the names of the schemas holding user tables,
+	// leaving out the system ones.
+	ListSchemas(db *sqlx.DB) ([]string, error)
+	// ListTables returns the names of the user tables in a schema.
+	ListTables(db *sqlx.DB, schema string) ([]string, error)
+	// DescribeColumns returns the columns of a table in their ordinal order.
+	DescribeColumns(db *sqlx.DB, schema, table string) ([]Column, error)
+	// PrimaryKey returns the primary key columns of a table in key order,
+	// or none if the table has no primary key.
+	PrimaryKey(db *sqlx.DB, schema, table string) ([]string, error)
+	// Paginate returns the clause limiting a query to a single page of rows.
+	Paginate(limit, offset int) string
+	// QuoteIdentifier quotes a table or column name.
+	QuoteIdentifier(name string) string
+	// Placeholder returns the parameter placeholder for the nth bound value,
+	// starting at 1.
+	Placeholder(n int) string
+	// ServerInfo reports the server version, the current user and the state
+	// of the session.
+	ServerInfo(db *sqlx.DB) (ServerInfo, error)
+}
+
+// QualifiedName quotes the name of a table in schema, so it names the same
+// table whatever the search path or current database of the session.
+func QualifiedName(d Dialect, schema, table string) string {
+	return d.QuoteIdentifier(schema) + "." + d.QuoteIdentifier(table)
+}
+
+// FileBased is implemented by dialects whose database is a local file,
+// referenced by DBConfig.Database, rather than a server.
+type FileBased interface {
+	FileBased() bool
+}
+
+// IsFileBased reports whether the named driver stores its database in a
+// local file, in which case host, port, user and password are not used.
+func IsFileBased(name string) bool {
+	d, err := Get(name)
+	if err != nil {
+		return false
+	}
+	f, ok := d.(FileBased)
+	return ok && f.FileBased()
+}
+
+// RowIDer is implemented by dialects with a hidden column identifying every
+// row, which orders the rows of tables without a primary key. Views have no
+// such column, or one that does not identify their rows, so it is only used
+// where HasRowID reports it.
+type RowIDer interface {
+	RowID() string
+	HasRowID(db *sqlx.DB, schema, table string) (bool, error)
+}
+
+// DefaultSchemaer is implemented by dialects whose schemas are the databases
+// of the server, so the database of a config is the schema to start at.
+type DefaultSchemaer interface {
+	DefaultSchema(cfg config.DBConfig) string
+}
+
+// DefaultPorter is implemented by dialects whose server listens on a well
+// known port.
+type DefaultPorter interface {
+	DefaultPort() int
+}
+
+// DefaultPort returns the well known port of the named driver, or 0 if it
+// has none.
+func DefaultPort(name string) int {
+	d, err := Get(name)
+	if err != nil {
+		return 0
+	}
+	if p, ok := d.(DefaultPorter); ok {
+		return p.DefaultPort()
+	}
+	return 0
+}
+
+// ansi provides the standard SQL pagination and identifier quoting shared
+// by most dialects.
+type ansi struct{}
+
+func (ansi) Paginate(limit, offset int) string {
+	return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+}
+
+func (ansi) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (ansi) Placeholder(n int) string {
+	return "?"
+}
+
+var drivers = map[string]Dialect{}
+
+// Register makes a dialect available under the given driver name.
+// It panics if a dialect is registered twice under the same name.
+func Register(name string, d Dialect) {
+	name = strings.ToLower(name)
+	if _, dup := drivers[name]; dup {
+		panic("dialect: Register called twice for driver " + name)
+	}
+	drivers[name] = d
+}
+
+// Get returns the dialect registered under the given driver name.
+func Get(name string) (Dialect, error) {
+	d, ok := drivers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver: %s", name)
+	}
+	return d, nil
+}
+
+// Drivers returns the sorted names of all registered drivers.
+func Drivers() []string {
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
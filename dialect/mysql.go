@@ -0,0 +1,189 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package dialect
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// mysqlDialect also serves MariaDB, which shares its protocol and information_schema.
+type mysqlDialect struct {
+	ansi
+	// readOnlyVar is the session variable making transactions read-only,
+	// MySQL 8 dropped the tx_read_only name MariaDB still uses.
+	readOnlyVar string
+}
+
+func init() {
+	Register("mysql", mysqlDialect{readOnlyVar: "transaction_read_only"})
+	Register("mariadb", mysqlDialect{readOnlyVar: "tx_read_only"})
+}
+
+func (mysqlDialect) DriverName() string { return "mysql" }
+
+func (mysqlDialect) DefaultPort() int { return 3306 }
+
+func (mysqlDialect) DefaultSchema(cfg config.DBConfig) string { return cfg.Database }
+
+func (d mysqlDialect) DSN(cfg config.DBConfig) (string, error) {
+	c := mysql.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	c.DBName = cfg.Database
+	c.ParseTime = true
+	if cfg.ReadOnly {
+		// The driver sets unknown parameters as session variables on connect.
+		c.Params = map[string]string{d.readOnlyVar: "1"}
+	}
+
+	tlsConfig, err := mysqlTLSConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	c.TLSConfig = tlsConfig
+	return c.FormatDSN(), nil
+}
+
+// mysqlTLSConfig maps the postgres style sslmode onto the driver's tls
+// parameter. Certificates can only be passed to the driver through a
+// registered tls.Config, which is keyed by the config ID.
+func mysqlTLSConfig(cfg config.DBConfig) (string, error) {
+	withCerts := cfg.SSLRootCert != "" || cfg.SSLCert != ""
+	switch {
+	case cfg.SSLMode == "disable":
+		return "false", nil
+	case cfg.SSLMode == "" && !withCerts:
+		return "", nil
+	case cfg.SSLMode == "require" && !withCerts:
+		return "skip-verify", nil
+	case cfg.SSLMode == "verify-full" && !withCerts:
+		return "true", nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.SSLRootCert != "" {
+		pem, err := os.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			return "", err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return "", fmt.Errorf("no certificates found in %s", cfg.SSLRootCert)
+		}
+	}
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return "", err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch cfg.SSLMode {
+	case "require":
+		tlsConfig.InsecureSkipVerify = true
+	case "verify-ca":
+		// Verify the chain but not the host name, like libpq does.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+			opts := x509.VerifyOptions{Roots: tlsConfig.RootCAs, Intermediates: x509.NewCertPool()}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+
+	name := "anydb-" + cfg.ID.String()
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ListSchemas returns the databases of the server, which MySQL calls
+// schemas, leaving out its own.
+func (mysqlDialect) ListSchemas(db *sqlx.DB) ([]string, error) {
+	var schemas []string
+	query := `SELECT schema_name FROM information_schema.schemata
+		WHERE schema_name NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+		ORDER BY schema_name`
+	if err := db.Select(&schemas, query); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+func (mysqlDialect) ListTables(db *sqlx.DB, schema string) ([]string, error) {
+	var tables []string
+	query := `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = ?
+		ORDER BY table_name`
+	if err := db.Select(&tables, query, schema); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (mysqlDialect) DescribeColumns(db *sqlx.DB, schema, table string) ([]Column, error) {
+	var columns []Column
+	query := `SELECT column_name AS name, column_type AS type, is_nullable = 'YES' AS nullable
+		FROM information_schema.columns
+		WHERE table_schema = ? AND table_name = ?
+		ORDER BY ordinal_position`
+	if err := db.Select(&columns, query, schema, table); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (mysqlDialect) PrimaryKey(db *sqlx.DB, schema, table string) ([]string, error) {
+	var columns []string
+	query := `SELECT column_name FROM information_schema.key_column_usage
+		WHERE table_schema = ? AND table_name = ? AND constraint_name = 'PRIMARY'
+		ORDER BY ordinal_position`
+	if err := db.Select(&columns, query, schema, table); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (d mysqlDialect) ServerInfo(db *sqlx.DB) (ServerInfo, error) {
+	var info ServerInfo
+	// @@read_only is set for the whole server, the session is read-only
+	// through the variable set when connecting
+	query := fmt.Sprintf("SELECT VERSION(), CURRENT_USER(), @@session.%s = 1", d.readOnlyVar)
+	if err := db.QueryRow(query).Scan(&info.Version, &info.User, &info.ReadOnly); err != nil {
+		return ServerInfo{}, err
+	}
+
+	var name string
+	if err := db.QueryRow("SHOW SESSION STATUS LIKE 'Ssl_version'").Scan(&name, &info.TLS); err != nil {
+		return ServerInfo{}, err
+	}
+	return info, nil
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
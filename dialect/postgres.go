@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package dialect
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/lib/pq"
+)
+
+type postgres struct {
+	ansi
+	sslMode string
+	port    int
+}
+
+func init() {
+	Register("postgres", postgres{sslMode: "disable", port: 5432})
+}
+
+func (postgres) DriverName() string { return "postgres" }
+
+func (p postgres) DefaultPort() int { return p.port }
+
+func (p postgres) DSN(cfg config.DBConfig) (string, error) {
+	query := url.Values{}
+	query.Set("sslmode", p.sslMode)
+	if cfg.SSLMode != "" {
+		query.Set("sslmode", cfg.SSLMode)
+	}
+	if cfg.ReadOnly {
+		// lib/pq sends unknown parameters as session settings on connect.
+		query.Set("default_transaction_read_only", "on")
+	}
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	if cfg.SSLCert != "" {
+		query.Set("sslcert", cfg.SSLCert)
+	}
+	if cfg.SSLKey != "" {
+		query.Set("sslkey", cfg.SSLKey)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// ListSchemas leaves out the catalogs of PostgreSQL and CockroachDB, and the
+// pg_temp and pg_toast schemas of every session.
+func (postgres) ListSchemas(db *sqlx.DB) ([]string, error) {
+	var schemas []string
+	query := `SELECT schema_name FROM information_schema.schemata
+		WHERE schema_name NOT IN ('information_schema', 'crdb_internal') AND schema_name NOT LIKE 'pg\_%'
+		ORDER BY schema_name`
+	if err := db.Select(&schemas, query); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+func (postgres) ListTables(db *sqlx.DB, schema string) ([]string, error) {
+	var tables []string
+	query := `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = $1
+		ORDER BY table_name`
+	if err := db.Select(&tables, query, schema); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (postgres) DescribeColumns(db *sqlx.DB, schema, table string) ([]Column, error) {
+	var columns []Column
+	query := `SELECT column_name AS name, data_type AS type, is_nullable = 'YES' AS nullable
+		FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2
+		ORDER BY ordinal_position`
+	if err := db.Select(&columns, query, schema, table); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (postgres) PrimaryKey(db *sqlx.DB, schema, table string) ([]string, error) {
+	var columns []string
+	// information_schema only shows the constraints of tables the user owns
+	// or may modify, the catalog shows those of every table.
+	query := `SELECT a.attname
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY (con.conkey)
+		WHERE con.contype = 'p' AND n.nspname = $1 AND c.relname = $2
+		ORDER BY array_position(con.conkey, a.attnum)`
+	if err := db.Select(&columns, query, schema, table); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (postgres) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// RowID is the physical location of a row, stable as long as it is not updated.
+func (postgres) RowID() string { return "ctid" }
+
+// HasRowID reports whether table is an ordinary table or a materialized
+// view. The ctid of a partitioned table is only unique within a partition.
+func (postgres) HasRowID(db *sqlx.DB, schema, table string) (bool, error) {
+	var ok bool
+	query := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'm'))`
+	err := db.Get(&ok, query, schema, table)
+	return ok, err
+}
+
+func (postgres) ServerInfo(db *sqlx.DB) (ServerInfo, error) {
+	var info ServerInfo
+	query := `SELECT version(), current_user, current_setting('transaction_read_only') = 'on'`
+	if err := db.QueryRow(query).Scan(&info.Version, &info.User, &info.ReadOnly); err != nil {
+		return ServerInfo{}, err
+	}
+
+	// "PostgreSQL 16.2 (Debian ...) on x86_64..." and "CockroachDB CCL v23.1.11 (x86_64...)"
+	info.Version, _, _ = strings.Cut(info.Version, " (")
+	info.Version, _, _ = strings.Cut(info.Version, " on ")
+
+	// pg_stat_ssl is not available everywhere, CockroachDB lacks it.
+	query = `SELECT COALESCE((SELECT version FROM pg_stat_ssl WHERE pid = pg_backend_pid() AND ssl), '')`
+	if err := db.QueryRow(query).Scan(&info.TLS); err != nil {
+		info.TLS = "unknown"
+	}
+	return info, nil
+}
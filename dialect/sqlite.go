@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqlite opens the database file stored in DBConfig.Database.
+type sqlite struct {
+	ansi
+}
+
+func init() {
+	Register("sqlite", sqlite{})
+}
+
+func (sqlite) DriverName() string { return "sqlite3" }
+
+func (sqlite) FileBased() bool { return true }
+
+func (sqlite) DSN(cfg config.DBConfig) (string, error) {
+	if strings.TrimSpace(cfg.Database) == "" {
+		return "", fmt.Errorf("sqlite database file path is empty")
+	}
+	// mode=rw keeps a mistyped path from silently creating an empty database.
+	// Read-only configs also set query_only, so the session reports itself
+	// read-only like the server dialects do.
+	path := sqliteURIPath.Replace(cfg.Database)
+	if cfg.ReadOnly {
+		return "file:" + path + "?mode=ro&_query_only=1", nil
+	}
+	return "file:" + path + "?mode=rw", nil
+}
+
+// sqliteURIPath escapes the characters that end the path of a file: URI, or
+// are decoded in it, so they stay part of the file name.
+var sqliteURIPath = strings.NewReplacer("%", "%25", "?", "%3F", "#", "%23")
+
+// ListSchemas returns main and the attached databases, which SQLite calls
+// schemas.
+func (sqlite) ListSchemas(db *sqlx.DB) ([]string, error) {
+	var schemas []string
+	if err := db.Select(&schemas, "SELECT name FROM pragma_database_list ORDER BY seq"); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+func (s sqlite) ListTables(db *sqlx.DB, schema string) ([]string, error) {
+	var tables []string
+	// Every schema has its own sqlite_master, it cannot be bound as a parameter.
+	query := fmt.Sprintf("SELECT name FROM %s WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%%' ORDER BY name",
+		QualifiedName(s, schema, "sqlite_master"))
+	if err := db.Select(&tables, query); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (sqlite) DescribeColumns(db *sqlx.DB, schema, table string) ([]Column, error) {
+	var columns []Column
+	query := `SELECT name, type, "notnull" = 0 AS nullable FROM pragma_table_info(?, ?) ORDER BY cid`
+	if err := db.Select(&columns, query, table, schema); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (sqlite) PrimaryKey(db *sqlx.DB, schema, table string) ([]string, error) {
+	var columns []string
+	query := "SELECT name FROM pragma_table_info(?, ?) WHERE pk > 0 ORDER BY pk"
+	if err := db.Select(&columns, query, table, schema); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// RowID is the integer key of every table not declared WITHOUT ROWID, which
+// have a primary key anyway.
+func (sqlite) RowID() string { return "rowid" }
+
+// HasRowID reports whether table is a table, the rowid of a view is not
+// stable from one query to the next.
+func (s sqlite) HasRowID(db *sqlx.DB, schema, table string) (bool, error) {
+	var ok bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE type = 'table' AND name = ?)",
+		QualifiedName(s, schema, "sqlite_master"))
+	err := db.Get(&ok, query, table)
+	return ok, err
+}
+
+func (sqlite) ServerInfo(db *sqlx.DB) (ServerInfo, error) {
+	var info ServerInfo
+	if err := db.QueryRow("SELECT 'SQLite ' || sqlite_version()").Scan(&info.Version); err != nil {
+		return ServerInfo{}, err
+	}
+	if err := db.QueryRow("PRAGMA query_only").Scan(&info.ReadOnly); err != nil {
+		return ServerInfo{}, err
+	}
+	return info, nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BinaryEncodings are the values of Format.Binary.
+var BinaryEncodings = []string{"hex", "base64"}
+
+// Format tells how values are turned into text.
+type Format struct {
+	// Null is the text of NULL values.
+	Null string
+	// Binary is the encoding of binary values, hex or base64.
+	Binary string
+	// UTC shows timestamps in UTC rather than in local time.
+	UTC bool
+	// PrettyJSON indents JSON documents over several lines, they are
+	// compacted onto one otherwise.
+	PrettyJSON bool
+}
+
+// DefaultFormat shows NULL as NULL, binary values in hex and timestamps in
+// local time.
+var DefaultFormat = Format{Null: "NULL", Binary: "hex"}
+
+// formatters turn the values of each kind of column into text. Values are
+// never nil, NULL is handled before.
+var formatters = map[Kind]func(f Format, column Column, value interface{}) string{
+	KindText:   formatText,
+	KindNumber: formatNumber,
+	KindBool:   formatBool,
+	KindBinary: formatBinary,
+	KindJSON:   formatJSON,
+	KindTime:   formatTime,
+}
+
+// Value returns value, read from column, as text.
+func (f Format) Value(column Column, value interface{}) string {
+	if value == nil {
+		return f.Null
+	}
+	return formatters[column.Kind()](f, column, value)
+}
+
+// Row returns the values of row as text, in the order of the columns of rs.
+func (f Format) Row(rs ResultSet, row Row) []string {
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = f.Value(rs.Columns[i], value)
+	}
+	return values
+}
+
+func formatText(f Format, column Column, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return formatTime(f, column, v)
+	}
+	return fmt.Sprint(value)
+}
+
+func formatNumber(f Format, column Column, value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		// %v switches to exponents for large and small values
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return formatText(f, column, value)
+}
+
+func formatBool(f Format, column Column, value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		// SQLite and MySQL store booleans as integers
+		return strconv.FormatBool(v != 0)
+	}
+	return formatText(f, column, value)
+}
+
+func formatBinary(f Format, column Column, value interface{}) string {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return formatText(f, column, value)
+	}
+	if f.Binary == "base64" {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return "\\x" + hex.EncodeToString(b)
+}
+
+func formatJSON(f Format, column Column, value interface{}) string {
+	text := formatText(f, column, value)
+	var buf bytes.Buffer
+	var err error
+	if f.PrettyJSON {
+		err = json.Indent(&buf, []byte(text), "", "  ")
+	} else {
+		err = json.Compact(&buf, []byte(text))
+	}
+	if err != nil {
+		// Shown as stored rather than hidden when it does not parse
+		return text
+	}
+	return buf.String()
+}
+
+func formatTime(f Format, column Column, value interface{}) string {
+	t, ok := value.(time.Time)
+	if !ok {
+		return formatText(f, column, value)
+	}
+	// Dates and timestamps without time zone have no zone to convert from,
+	// the drivers read them as UTC and they are shown as stored
+	switch {
+	case column.Type == "date":
+		return t.Format(time.DateOnly)
+	case strings.HasPrefix(column.Type, "datetime") || strings.HasSuffix(column.Type, "without time zone"):
+		return t.Format("2006-01-02 15:04:05.999999999")
+	}
+	if f.UTC {
+		t = t.UTC()
+	} else {
+		t = t.Local()
+	}
+	layout := "2006-01-02 15:04:05.999999999 -07:00"
+	if t.Location() == time.UTC {
+		layout = "2006-01-02 15:04:05.999999999Z"
+	}
+	return t.Format(layout)
+}
+
+// ParseBinaryEncoding checks the name of a binary encoding.
+func ParseBinaryEncoding(name string) (string, error) {
+	name = strings.ToLower(name)
+	for _, encoding := range BinaryEncodings {
+		if name == encoding {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown binary encoding %q, use %s", name, strings.Join(BinaryEncodings, " or "))
+}
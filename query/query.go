@@ -0,0 +1,171 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+
+// Package query builds the statements reading table data. Identifiers are
+// quoted by the dialect of the connection and values are bound as
+// parameters, so no table name, column name or value can change the meaning
+// of a statement. Code reading user tables goes through it rather than
+// formatting SQL itself.
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/dialect"
+)
+
+// operators are the comparisons accepted by Where and WhereRow.
+var operators = map[string]bool{
+	"=":  true,
+	"<>": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+}
+
+// Builder builds a SELECT statement. Its methods return the builder so calls
+// can be chained, the first error is reported by Build.
+type Builder struct {
+	d       dialect.Dialect
+	columns []string
+	from    string
+	where   []string
+	args    []interface{}
+	orderBy []string
+	page    string
+	err     error
+}
+
+// Select starts a statement for the dialect d.
+func Select(d dialect.Dialect) *Builder {
+	return &Builder{d: d}
+}
+
+// Columns selects the named columns.
+func (b *Builder) Columns(names ...string) *Builder {
+	for _, name := range names {
+		b.columns = append(b.columns, b.d.QuoteIdentifier(name))
+	}
+	return b
+}
+
+// ColumnAs selects the named column under another name.
+func (b *Builder) ColumnAs(name, alias string) *Builder {
+	b.columns = append(b.columns, b.d.QuoteIdentifier(name)+" AS "+b.d.QuoteIdentifier(alias))
+	return b
+}
+
+// AllColumns selects every column of the table.
+func (b *Builder) AllColumns() *Builder {
+	b.columns = append(b.columns, "*")
+	return b
+}
+
+// Count selects the number of rows.
+func (b *Builder) Count() *Builder {
+	b.columns = append(b.columns, "COUNT(*)")
+	return b
+}
+
+// From reads table in schema.
+func (b *Builder) From(schema, table string) *Builder {
+	b.from = dialect.QualifiedName(b.d, schema, table)
+	return b
+}
+
+// Where keeps the rows whose column compares to value with op.
+func (b *Builder) Where(column, op string, value interface{}) *Builder {
+	return b.WhereRow([]string{column}, op, []interface{}{value})
+}
+
+// WhereRow keeps the rows whose columns, compared as a row, compare to values
+// with op. Rows compare column by column like strings do letter by letter,
+// which reads the rows after or before a key of several columns.
+func (b *Builder) WhereRow(columns []string, op string, values []interface{}) *Builder {
+	switch {
+	case !operators[op]:
+		b.fail(fmt.Errorf("unsupported operator %q", op))
+		return b
+	case len(columns) == 0 || len(columns) != len(values):
+		b.fail(fmt.Errorf("%d columns compared to %d values", len(columns), len(values)))
+		return b
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = b.d.QuoteIdentifier(column)
+	}
+	// Placeholders are numbered as they are written, rebinding the whole
+	// statement would also rewrite a ? in a quoted name
+	placeholders := make([]string, len(values))
+	for i := range values {
+		placeholders[i] = b.d.Placeholder(len(b.args) + i + 1)
+	}
+	if len(columns) == 1 {
+		b.where = append(b.where, fmt.Sprintf("%s %s %s", quoted[0], op, placeholders[0]))
+	} else {
+		b.where = append(b.where, fmt.Sprintf("(%s) %s (%s)", strings.Join(quoted, ", "), op, strings.Join(placeholders, ", ")))
+	}
+	b.args = append(b.args, values...)
+	return b
+}
+
+// OrderBy sorts the rows on column, in descending order with desc. Calls
+// add columns to sort on when the previous ones are equal.
+func (b *Builder) OrderBy(column string, desc bool) *Builder {
+	order := b.d.QuoteIdentifier(column)
+	if desc {
+		order += " DESC"
+	}
+	b.orderBy = append(b.orderBy, order)
+	return b
+}
+
+// Paginate reads limit rows, skipping the first offset ones.
+func (b *Builder) Paginate(limit, offset int) *Builder {
+	if limit < 1 || offset < 0 {
+		b.fail(fmt.Errorf("invalid page of %d rows at offset %d", limit, offset))
+		return b
+	}
+	b.page = b.d.Paginate(limit, offset)
+	return b
+}
+
+// Build returns the statement, with the placeholders of the dialect, and
+// the values to bind to them.
+func (b *Builder) Build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.from == "" {
+		return "", nil, fmt.Errorf("no table to select from")
+	}
+
+	columns := b.columns
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + b.from
+	if len(b.where) > 0 {
+		query += " WHERE " + strings.Join(b.where, " AND ")
+	}
+	if len(b.orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(b.orderBy, ", ")
+	}
+	if b.page != "" {
+		query += " " + b.page
+	}
+	return query, b.args, nil
+}
+
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnyoneClown/anydb/dialect"
+)
+
+func mustDialect(t *testing.T, driver string) dialect.Dialect {
+	t.Helper()
+	d, err := dialect.Get(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driver string
+		name   string
+		want   string
+	}{
+		{"postgres", "users", `"users"`},
+		{"postgres", `we"ird`, `"we""ird"`},
+		{"postgres", "order", `"order"`},
+		{"cockroachdb", `a"b`, `"a""b"`},
+		{"sqlite", `x"; DROP TABLE t; --`, `"x""; DROP TABLE t; --"`},
+		{"mysql", "users", "`users`"},
+		{"mysql", "we`ird", "`we``ird`"},
+		{"mariadb", `a"b`, "`a\"b`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver+"/"+tt.name, func(t *testing.T) {
+			if got := mustDialect(t, tt.driver).QuoteIdentifier(tt.name); got != tt.want {
+				t.Errorf("QuoteIdentifier(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   string
+		build    func(b *Builder) *Builder
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:   "all columns",
+			driver: "postgres",
+			build:  func(b *Builder) *Builder { return b.From("public", "users") },
+			want:   `SELECT * FROM "public"."users"`,
+		},
+		{
+			name:   "count",
+			driver: "mysql",
+			build:  func(b *Builder) *Builder { return b.Count().From("shop", "order") },
+			want:   "SELECT COUNT(*) FROM `shop`.`order`",
+		},
+		{
+			name:   "columns and alias",
+			driver: "sqlite",
+			build: func(b *Builder) *Builder {
+				return b.ColumnAs("rowid", "anydb_rowid").AllColumns().From("main", "t")
+			},
+			want: `SELECT "rowid" AS "anydb_rowid", * FROM "main"."t"`,
+		},
+		{
+			name:   "where binds postgres placeholders",
+			driver: "postgres",
+			build: func(b *Builder) *Builder {
+				return b.Columns("id", "name").From("public", "users").Where("id", ">=", 10).Where("name", "<>", "x")
+			},
+			want:     `SELECT "id", "name" FROM "public"."users" WHERE "id" >= $1 AND "name" <> $2`,
+			wantArgs: []interface{}{10, "x"},
+		},
+		{
+			name:   "where binds mysql placeholders",
+			driver: "mysql",
+			build: func(b *Builder) *Builder {
+				return b.From("shop", "users").Where("na`me", "=", "'; --")
+			},
+			want:     "SELECT * FROM `shop`.`users` WHERE `na``me` = ?",
+			wantArgs: []interface{}{"'; --"},
+		},
+		{
+			name:   "row comparison",
+			driver: "postgres",
+			build: func(b *Builder) *Builder {
+				return b.From("public", "t").WhereRow([]string{"a", "b"}, "<", []interface{}{1, "z"})
+			},
+			want:     `SELECT * FROM "public"."t" WHERE ("a", "b") < ($1, $2)`,
+			wantArgs: []interface{}{1, "z"},
+		},
+		{
+			name:   "question mark in names",
+			driver: "postgres",
+			build: func(b *Builder) *Builder {
+				return b.From("public", "what?").Where("id?", ">", 1).Where("$1", "=", "x")
+			},
+			want:     `SELECT * FROM "public"."what?" WHERE "id?" > $1 AND "$1" = $2`,
+			wantArgs: []interface{}{1, "x"},
+		},
+		{
+			name:   "question mark in cockroachdb names",
+			driver: "cockroachdb",
+			build: func(b *Builder) *Builder {
+				return b.Columns("a?b").From("public", "t").WhereRow([]string{"x", "y?"}, ">", []interface{}{1, 2})
+			},
+			want:     `SELECT "a?b" FROM "public"."t" WHERE ("x", "y?") > ($1, $2)`,
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name:     "question mark in mysql names",
+			driver:   "mysql",
+			build:    func(b *Builder) *Builder { return b.From("shop", "what?").Where("id", "=", 1) },
+			want:     "SELECT * FROM `shop`.`what?` WHERE `id` = ?",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:   "order and page",
+			driver: "sqlite",
+			build: func(b *Builder) *Builder {
+				return b.From("main", "t").OrderBy("a", false).OrderBy("b", true).Paginate(20, 40)
+			},
+			want: `SELECT * FROM "main"."t" ORDER BY "a", "b" DESC LIMIT 20 OFFSET 40`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := tt.build(Select(mustDialect(t, tt.driver))).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *Builder) *Builder
+	}{
+		{"no table", func(b *Builder) *Builder { return b.AllColumns() }},
+		{"unknown operator", func(b *Builder) *Builder { return b.From("s", "t").Where("a", "; DROP", 1) }},
+		{"like operator", func(b *Builder) *Builder { return b.From("s", "t").Where("a", "LIKE", "%") }},
+		{"no columns", func(b *Builder) *Builder { return b.From("s", "t").WhereRow(nil, "=", nil) }},
+		{"fewer values", func(b *Builder) *Builder {
+			return b.From("s", "t").WhereRow([]string{"a", "b"}, "=", []interface{}{1})
+		}},
+		{"no rows", func(b *Builder) *Builder { return b.From("s", "t").Paginate(0, 0) }},
+		{"negative offset", func(b *Builder) *Builder { return b.From("s", "t").Paginate(10, -1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, err := tt.build(Select(mustDialect(t, "postgres"))).Build(); err == nil {
+				t.Errorf("Build() = %s, want an error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package query
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Kind groups the database types of columns formatted alike.
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindNumber
+	KindBool
+	KindBinary
+	KindJSON
+	KindTime
+)
+
+// Column describes a column of a result set.
+type Column struct {
+	Name string
+	// Type is the database type of the column in lower case, such as jsonb
+	// or varchar(255).
+	Type     string
+	Nullable bool
+}
+
+// numberTypes are the numeric database types, without their precision.
+var numberTypes = map[string]bool{
+	"int": true, "integer": true, "int2": true, "int4": true, "int8": true,
+	"tinyint": true, "smallint": true, "mediumint": true, "bigint": true,
+	"serial": true, "smallserial": true, "bigserial": true,
+	"numeric": true, "decimal": true, "real": true, "double": true,
+	"float": true, "float4": true, "float8": true, "money": true,
+}
+
+// Kind tells how the values of the column are formatted.
+func (c Column) Kind() Kind {
+	// "numeric(10,2)", "bigint unsigned" and "double precision" are
+	// classified by their first word
+	base := strings.FieldsFunc(c.Type, func(r rune) bool { return r == '(' || r == ' ' })
+	if len(base) == 0 {
+		return KindText
+	}
+	switch t := base[0]; {
+	case strings.Contains(t, "json"):
+		return KindJSON
+	case t == "bytea" || strings.HasSuffix(t, "blob") || strings.HasSuffix(t, "binary"):
+		return KindBinary
+	case t == "timestamp" || t == "timestamptz" || t == "datetime" || t == "date":
+		return KindTime
+	case t == "bool" || t == "boolean":
+		return KindBool
+	case numberTypes[t]:
+		return KindNumber
+	}
+	return KindText
+}
+
+// Row holds the values of a row in the order of the columns, nil for NULL.
+type Row []interface{}
+
+// ResultSet holds the rows read by a statement together with the
+// description of their columns.
+type ResultSet struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// Index returns the position of the named column, or -1 if there is none.
+func (rs ResultSet) Index(name string) int {
+	for i, column := range rs.Columns {
+		if column.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Scan reads every row of rows. The columns are described as the driver
+// reports them. Text read as bytes, as the mysql driver does, is turned into
+// strings so only binary columns hold bytes.
+func Scan(rows *sqlx.Rows) (ResultSet, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return ResultSet{}, err
+	}
+
+	var rs ResultSet
+	rs.Columns = make([]Column, len(types))
+	for i, ct := range types {
+		nullable, ok := ct.Nullable()
+		rs.Columns[i] = Column{
+			Name:     ct.Name(),
+			Type:     strings.ToLower(ct.DatabaseTypeName()),
+			Nullable: nullable || !ok,
+		}
+	}
+
+	for rows.Next() {
+		row, err := rows.SliceScan()
+		if err != nil {
+			return ResultSet{}, err
+		}
+		for i, value := range row {
+			if b, ok := value.([]byte); ok && rs.Columns[i].Kind() != KindBinary {
+				row[i] = string(b)
+			}
+		}
+		rs.Rows = append(rs.Rows, row)
+	}
+	return rs, rows.Err()
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package secret
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider resolves the part of a secret reference after its scheme, for
+// example "PGPASSWORD_PROD" in "env:PGPASSWORD_PROD".
+type Provider interface {
+	Resolve(name string) (string, error)
+}
+
+// ProviderFunc adapts an ordinary function to the Provider interface.
+type ProviderFunc func(name string) (string, error)
+
+func (f ProviderFunc) Resolve(name string) (string, error) { return f(name) }
+
+var providers = map[string]Provider{}
+
+// Register makes a provider available under the given scheme.
+// It panics if a provider is registered twice under the same scheme.
+func Register(scheme string, p Provider) {
+	if _, dup := providers[scheme]; dup {
+		panic("secret: Register called twice for scheme " + scheme)
+	}
+	providers[scheme] = p
+}
+
+// Schemes returns the sorted names of all registered schemes.
+func Schemes() []string {
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Validate checks that a reference names a registered scheme and a secret,
+// without resolving it.
+func Validate(ref string) error {
+	_, _, err := parse(ref)
+	return err
+}
+
+// Scheme returns the scheme of a reference, such as "env" for
+// "env:PGPASSWORD", or "" if it has none.
+func Scheme(ref string) string {
+	scheme, _, ok := strings.Cut(ref, ":")
+	if !ok {
+		return ""
+	}
+	return scheme
+}
+
+// Resolve returns the secret a reference such as "file:/run/secrets/db"
+// points at.
+func Resolve(ref string) (string, error) {
+	p, name, err := parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := p.Resolve(name)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %q: %w", ref, err)
+	}
+	return value, nil
+}
+
+func parse(ref string) (Provider, string, error) {
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, "", fmt.Errorf("secret reference must look like <scheme>:<name>, supported schemes: %s", strings.Join(Schemes(), ", "))
+	}
+
+	p, ok := providers[scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported secret scheme %q, supported schemes: %s", scheme, strings.Join(Schemes(), ", "))
+	}
+	return p, name, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"github.com/AnyoneClown/anydb/config"
+	"gopkg.in/yaml.v2"
+)
+
+// ExportBundle returns configs as a file to share with a team. It has the
+// layout of the configuration file, so it can be used as one in another
+// home directory. Without a passphrase the passwords are stripped, with one
+// they are encrypted with a key derived from it, which then serves as the
+// master passphrase of the bundle. Password references are kept as they are.
+func ExportBundle(store ConfigStore, configs []config.DBConfig, passphrase string) ([]byte, error) {
+	bundle := config.ConfigFileData{Version: config.ConfigVersion}
+
+	var key []byte
+	if passphrase != "" {
+		var err error
+		key, bundle.Encryption, err = newEncryptionHeader(passphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, cfg := range configs {
+		password := cfg.Password
+		cfg.Password = ""
+		cfg.Source = ""
+
+		if key != nil && password != "" {
+			plaintext, err := store.DecryptPassword(password)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Password, err = encrypt(key, plaintext)
+			if err != nil {
+				return nil, err
+			}
+		}
+		bundle.Configs = append(bundle.Configs, cfg)
+	}
+
+	return yaml.Marshal(bundle)
+}
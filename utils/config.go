@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 
@@ -10,35 +13,163 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func LoadConfigs(file string) ([]config.DBConfig, error) {
-	var configs []config.DBConfig
+var (
+	ErrConfigNotFound   = errors.New("configuration not found")
+	ErrRevisionMismatch = errors.New("configuration was modified concurrently")
+)
+
+// ConfigEnv selects the config to connect to for a single invocation, like
+// the --config flag.
+const ConfigEnv = "ANYDB_CONFIG"
+
+// LoadConfigs reads the configs under the config file lock, migrating
+// older file versions on the way, merged with those of the project file.
+func (s *YAMLStore) LoadConfigs() ([]config.DBConfig, error) {
+	fileData, project, err := s.readConfigs()
+	if err != nil {
+		return nil, err
+	}
+	return mergeProjectConfigs(fileData.Configs, project), nil
+}
+
+// readConfigs reads the config file and the project file.
+func (s *YAMLStore) readConfigs() (config.ConfigFileData, config.ProjectFileData, error) {
+	var fileData config.ConfigFileData
+	err := withFileLock(s.configFile, func() error {
+		var err error
+		fileData, err = s.readConfigFile()
+		return err
+	})
+	if err != nil {
+		return config.ConfigFileData{}, config.ProjectFileData{}, err
+	}
+
+	// Unlocking prompts, so passwords left plaintext by a migration are
+	// encrypted once the lock is released, and the file read again.
+	if s.sealPending {
+		if err := s.Unlock(); err != nil {
+			Log.Warn("Passwords of the migrated configuration file stay unencrypted until unlocked", zap.Error(err))
+		} else {
+			return s.readConfigs()
+		}
+	}
+
+	project, err := s.readProjectFile(fileData.AllowedEnv)
+	if err != nil {
+		return config.ConfigFileData{}, config.ProjectFileData{}, err
+	}
+	return fileData, project, nil
+}
+
+// UpdateConfigs runs fn on the configs of the config file, without those of
+// the project file, and writes back its result,
+// holding the config file lock for the whole read-modify-write cycle so
+// concurrent writers from the CLI and the web server never lose updates.
+// New passwords should be passed through EncryptPassword before, since
+// unlocking may need to prompt and to write the file itself.
+func (s *YAMLStore) UpdateConfigs(fn func([]config.DBConfig) ([]config.DBConfig, error)) error {
+	return s.updateConfigFile(func(fileData *config.ConfigFileData) error {
+		var err error
+		fileData.Configs, err = fn(fileData.Configs)
+		return err
+	})
+}
+
+// updateConfigFile runs fn on the file data under the config file lock and
+// writes back the result. Plaintext passwords are encrypted once unlocked,
+// and a default pointing at a removed config is cleared.
+func (s *YAMLStore) updateConfigFile(fn func(*config.ConfigFileData) error) error {
+	return withFileLock(s.configFile, func() error {
+		fileData, err := s.readConfigFile()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&fileData); err != nil {
+			return err
+		}
+
+		if s.masterKey != nil {
+			for i, cfg := range fileData.Configs {
+				if cfg.Password == "" || IsEncrypted(cfg.Password) {
+					continue
+				}
+				fileData.Configs[i].Password, err = encrypt(s.masterKey, cfg.Password)
+				if err != nil {
+					Log.Error("Failed to encrypt password", zap.String("configName", cfg.ConfigName), zap.Error(err))
+					return err
+				}
+			}
+		}
+
+		if _, ok := defaultConfig(fileData); !ok {
+			fileData.Default = nil
+		}
+
+		return s.writeConfigFile(fileData)
+	})
+}
+
+// ConfigRevision identifies the stored state of a config. It changes on
+// every modification and is used as the ETag of the config in the API.
+func ConfigRevision(cfg config.DBConfig) string {
+	data, _ := yaml.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
 
-	data, err := os.ReadFile(file)
+// readConfigFile must be called with the config file lock held.
+func (s *YAMLStore) readConfigFile() (config.ConfigFileData, error) {
+	data, err := os.ReadFile(s.configFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return configs, nil
+			return config.ConfigFileData{}, nil
 		}
 		Log.Error("Failed to read configuration file", zap.Error(err))
-		return nil, err
+		return config.ConfigFileData{}, err
 	}
 
-	err = yaml.Unmarshal(data, &configs)
+	version, err := configFileVersion(data)
 	if err != nil {
 		Log.Error("Failed to unmarshal configuration data", zap.Error(err))
-		return nil, err
+		return config.ConfigFileData{}, err
+	}
+
+	switch {
+	case version < config.ConfigVersion:
+		return s.migrateConfigFile(data, version)
+	case version > config.ConfigVersion:
+		err := fmt.Errorf("configuration file version %d is newer than supported version %d", version, config.ConfigVersion)
+		Log.Error("Unsupported configuration file version", zap.Error(err))
+		return config.ConfigFileData{}, err
+	}
+
+	var fileData config.ConfigFileData
+	err = yaml.Unmarshal(data, &fileData)
+	if err != nil {
+		Log.Error("Failed to unmarshal configuration data", zap.Error(err))
+		return config.ConfigFileData{}, err
 	}
 
-	return configs, nil
+	for _, cfg := range fileData.Configs {
+		if err := ValidateConfig(cfg); err != nil {
+			Log.Warn("Invalid configuration", zap.String("configName", cfg.ConfigName), zap.Error(err))
+		}
+	}
+
+	return fileData, nil
 }
 
-func SaveConfigs(configs []config.DBConfig, file string) error {
-	data, err := yaml.Marshal(configs)
+// writeConfigFile must be called with the config file lock held.
+func (s *YAMLStore) writeConfigFile(fileData config.ConfigFileData) error {
+	fileData.Version = config.ConfigVersion
+	data, err := yaml.Marshal(fileData)
 	if err != nil {
 		Log.Error("Failed to marshal configuration data", zap.Error(err))
 		return err
 	}
 
-	err = os.WriteFile(file, data, 0644)
+	err = writePrivateFile(s.configFile, data)
 	if err != nil {
 		Log.Error("Failed to write configuration file", zap.Error(err))
 		return err
@@ -47,24 +178,39 @@ func SaveConfigs(configs []config.DBConfig, file string) error {
 	return nil
 }
 
-func LoadDefaultConfig() error {
-	data, err := os.ReadFile(config.DefaultConfigFile)
+// LoadDefaultConfig returns the config selected as default, which is
+// empty when none was selected yet. The default of the project file takes
+// precedence over the global one.
+func (s *YAMLStore) LoadDefaultConfig() (config.DBConfig, error) {
+	fileData, project, err := s.readConfigs()
 	if err != nil {
-		Log.Error("Failed to read default configuration file", zap.Error(err))
-		return err
+		return config.DBConfig{}, err
 	}
 
-	err = yaml.Unmarshal(data, &config.DefaultConfigData)
-	if err != nil {
-		Log.Error("Failed to unmarshal default configuration data", zap.Error(err))
-		return err
+	cfg, ok, err := projectDefault(fileData.Configs, project)
+	if err != nil || ok {
+		return cfg, err
 	}
 
-	return nil
+	cfg, _ = defaultConfig(fileData)
+	return cfg, nil
+}
+
+// defaultConfig returns the config the default ID points at.
+func defaultConfig(fileData config.ConfigFileData) (config.DBConfig, bool) {
+	if fileData.Default == nil {
+		return config.DBConfig{}, false
+	}
+	for _, cfg := range fileData.Configs {
+		if cfg.ID == *fileData.Default {
+			return cfg, true
+		}
+	}
+	return config.DBConfig{}, false
 }
 
-func GetConfigByID(id uuid.UUID) (*config.DBConfig, error) {
-	configs, err := LoadConfigs(config.ConfigFile)
+func (s *YAMLStore) GetConfigByID(id uuid.UUID) (*config.DBConfig, error) {
+	configs, err := s.LoadConfigs()
 	if err != nil {
 		return nil, err
 	}
@@ -76,5 +222,85 @@ func GetConfigByID(id uuid.UUID) (*config.DBConfig, error) {
 	}
 
 	Log.Error("Configuration not found", zap.String("id", id.String()))
-	return nil, fmt.Errorf("configuration with ID %s not found", id)
+	return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, id)
+}
+
+// FindConfig returns the config with the given ID or name. A name shared by
+// several configs is rejected, the ID has to be used then.
+func FindConfig(store ConfigStore, nameOrID string) (*config.DBConfig, error) {
+	configs, err := store.LoadConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	if id, err := uuid.Parse(nameOrID); err == nil {
+		for _, cfg := range configs {
+			if cfg.ID == id {
+				return &cfg, nil
+			}
+		}
+	}
+
+	var found *config.DBConfig
+	for i, cfg := range configs {
+		if cfg.ConfigName != nameOrID {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("several configurations are named %q, select one by ID", nameOrID)
+		}
+		found = &configs[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, nameOrID)
+	}
+	return found, nil
+}
+
+// ActiveConfig returns the config to connect to. selector is a config name or
+// ID, usually the --config flag; when empty ANYDB_CONFIG is used, and then
+// the default config.
+func ActiveConfig(store ConfigStore, selector string) (config.DBConfig, error) {
+	if selector == "" {
+		selector = os.Getenv(ConfigEnv)
+	}
+	if selector != "" {
+		cfg, err := FindConfig(store, selector)
+		if err != nil {
+			return config.DBConfig{}, err
+		}
+		return *cfg, nil
+	}
+
+	defaultConfig, err := store.LoadDefaultConfig()
+	if err != nil {
+		return config.DBConfig{}, err
+	}
+	if defaultConfig.ID == uuid.Nil {
+		return config.DBConfig{}, fmt.Errorf("no default configuration, select one with anydb configure or pass --config")
+	}
+	return defaultConfig, nil
+}
+
+// SetDefaultConfig selects the config with the given ID as global default.
+// Configs of the project file cannot be selected, the project file sets
+// their default itself.
+func (s *YAMLStore) SetDefaultConfig(id uuid.UUID) error {
+	return s.updateConfigFile(func(fileData *config.ConfigFileData) error {
+		for _, cfg := range fileData.Configs {
+			if cfg.ID == id {
+				fileData.Default = &id
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrConfigNotFound, id)
+	})
+}
+
+// ClearDefaultConfig unselects the default config.
+func (s *YAMLStore) ClearDefaultConfig() error {
+	return s.updateConfigFile(func(fileData *config.ConfigFileData) error {
+		fileData.Default = nil
+		return nil
+	})
 }
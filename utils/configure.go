@@ -11,51 +11,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/AnyoneClown/anydb/config"
-	"go.uber.org/zap"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/secret"
 )
 
-func CreateFileAndDir() error {
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, ".anydb")
-	config.ConfigFile = filepath.Join(configDir, "anydb-config.yaml")
-	config.DefaultConfigFile = filepath.Join(configDir, "anydb-default-config.yaml")
-
-	// Check if the directory exists, if not, create it
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		err = os.Mkdir(configDir, os.ModePerm)
-		if err != nil {
-			Log.Error("Failed to create configDir", zap.String("configDir", configDir), zap.Error(err))
-			return err
-		}
-	}
-
-	// Check if the config file exists, if not, create it
-	if _, err := os.Stat(config.ConfigFile); os.IsNotExist(err) {
-		file, err := os.Create(config.ConfigFile)
-		if err != nil {
-			Log.Error("Failed to create ConfigFile", zap.String("ConfigFile", config.ConfigFile), zap.Error(err))
-			return err
-		}
-		file.Close()
-	}
-
-	// Check if the default config file exists, if not, create it
-	if _, err := os.Stat(config.DefaultConfigFile); os.IsNotExist(err) {
-		file, err := os.Create(config.DefaultConfigFile)
-		if err != nil {
-			Log.Error("Failed to create DefaultConfigFile", zap.String("DefaultConfigFile", config.DefaultConfigFile), zap.Error(err))
-			return err
-		}
-		file.Close()
-	}
-
-	return nil
-}
-
 func ValidateNotEmpty(value string) error {
 	if strings.TrimSpace(value) == "" {
 		return fmt.Errorf("field cannot be empty")
@@ -65,19 +30,167 @@ func ValidateNotEmpty(value string) error {
 
 func ValidatePort(value string) error {
 	port, err := strconv.Atoi(value)
-	if err != nil || port <= 0 || port > 65535 {
+	if err != nil {
+		return fmt.Errorf("invalid port number")
+	}
+	return ValidatePortNumber(port)
+}
+
+func ValidatePortNumber(port int) error {
+	if port <= 0 || port > 65535 {
 		return fmt.Errorf("invalid port number")
 	}
 	return nil
 }
 
 func ValidateDatabaseDriver(value string) error {
-	value = strings.ToLower(value)
-	for _, driver := range config.SupportedDrivers {
-		if value == driver {
+	if _, err := dialect.Get(value); err == nil {
+		return nil
+	}
+	errorMessage := fmt.Sprintf("Supported drivers: %s", strings.Join(dialect.Drivers(), ", "))
+	return errors.New(errorMessage)
+}
+
+func ValidateSSLMode(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, mode := range config.SSLModes {
+		if value == mode {
 			return nil
 		}
 	}
-	errorMessage := fmt.Sprintf("Supported drivers: %s", strings.Join(config.SupportedDrivers, ", "))
+	errorMessage := fmt.Sprintf("Supported SSL modes: %s", strings.Join(config.SSLModes, ", "))
 	return errors.New(errorMessage)
 }
+
+func ValidatePasswordRef(value string) error {
+	if value == "" {
+		return nil
+	}
+	return secret.Validate(value)
+}
+
+// WebPasswordRefSchemes are the secret schemes accepted over the web API.
+// File and command references would let anyone reaching the server read
+// files or run commands as the user running it.
+var WebPasswordRefSchemes = []string{"env"}
+
+// ValidateWebPasswordRef checks a password reference set or resolved through
+// the web API. Only the environment variables in allowedEnv, set with anydb
+// configure allow-env, can be referenced, so a config created over HTTP
+// cannot send any other variable to a server as its password.
+func ValidateWebPasswordRef(value string, allowedEnv []string) error {
+	if err := ValidatePasswordRef(value); err != nil || value == "" {
+		return err
+	}
+	scheme := secret.Scheme(value)
+	if !slices.Contains(WebPasswordRefSchemes, scheme) {
+		return fmt.Errorf("%s: references are not accepted over the web API, use %s: or anydb configure",
+			scheme, strings.Join(WebPasswordRefSchemes, ":, "))
+	}
+	if name := strings.TrimPrefix(value, scheme+":"); !slices.Contains(allowedEnv, name) || ValidateAllowedEnv(name) != nil {
+		return fmt.Errorf("password reference %s is not allowed, allow it with anydb configure allow-env %s", value, name)
+	}
+	return nil
+}
+
+func ValidateOptionalFile(value string) error {
+	if value == "" {
+		return nil
+	}
+	info, err := os.Stat(value)
+	if err != nil || info.IsDir() {
+		return fmt.Errorf("file does not exist")
+	}
+	return nil
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func ValidateColor(value string) error {
+	if value == "" || hexColor.MatchString(value) {
+		return nil
+	}
+	return fmt.Errorf("color must be a hex color such as #dc2626")
+}
+
+// ParseYesNo reads the answer of a yes/no form field, empty means no.
+func ParseYesNo(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "n", "no", "false":
+		return false, nil
+	case "y", "yes", "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("answer yes or no")
+}
+
+func ValidateYesNo(value string) error {
+	_, err := ParseYesNo(value)
+	return err
+}
+
+// NormalizeConfig drops the fields cfg does not use before it is stored.
+// A referenced secret is resolved when connecting and never stored, and file
+// based drivers have no server to log in to.
+func NormalizeConfig(cfg config.DBConfig) config.DBConfig {
+	cfg.Driver = strings.ToLower(cfg.Driver)
+	cfg.Environment = strings.ToLower(cfg.Environment)
+	if cfg.PasswordRef != "" {
+		cfg.Password = ""
+	}
+	if dialect.IsFileBased(cfg.Driver) {
+		cfg.Host, cfg.Port, cfg.User, cfg.Password = "", 0, "", ""
+		// A relative path would open another file from another directory
+		if cfg.Database != "" && cfg.Database != ":memory:" {
+			if path, err := filepath.Abs(cfg.Database); err == nil {
+				cfg.Database = path
+			}
+		}
+	}
+	return cfg
+}
+
+// ValidateConfig checks a whole config with the same rules the configure
+// form applies to each field. Server fields are skipped for file based drivers.
+func ValidateConfig(cfg config.DBConfig) error {
+	if err := ValidateNotEmpty(cfg.ConfigName); err != nil {
+		return fmt.Errorf("config name: %w", err)
+	}
+	if err := ValidateDatabaseDriver(cfg.Driver); err != nil {
+		return fmt.Errorf("driver: %w", err)
+	}
+	if err := ValidateNotEmpty(cfg.Database); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	if err := ValidateSSLMode(cfg.SSLMode); err != nil {
+		return fmt.Errorf("sslmode: %w", err)
+	}
+	if err := ValidateColor(cfg.Color); err != nil {
+		return fmt.Errorf("color: %w", err)
+	}
+	if dialect.IsFileBased(cfg.Driver) {
+		return nil
+	}
+
+	if err := ValidateNotEmpty(cfg.Host); err != nil {
+		return fmt.Errorf("host: %w", err)
+	}
+	if err := ValidatePortNumber(cfg.Port); err != nil {
+		return fmt.Errorf("port: %w", err)
+	}
+	if err := ValidateNotEmpty(cfg.User); err != nil {
+		return fmt.Errorf("user: %w", err)
+	}
+	if cfg.PasswordRef != "" {
+		if err := ValidatePasswordRef(cfg.PasswordRef); err != nil {
+			return fmt.Errorf("password reference: %w", err)
+		}
+		return nil
+	}
+	if err := ValidateNotEmpty(cfg.Password); err != nil {
+		return fmt.Errorf("password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+)
+
+var ErrNotConfirmed = errors.New("confirmation does not match the database name")
+
+// CheckConfirmation accepts a write to cfg when it is not protected or when
+// typed is its database name.
+func CheckConfirmation(cfg config.DBConfig, typed string) error {
+	if !cfg.Protected() || strings.TrimSpace(typed) == cfg.Database {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNotConfirmed, cfg.ConfigName)
+}
+
+// ConfirmProtected asks to type the database name of a protected config
+// before action is applied to it. Unprotected configs need no confirmation.
+func ConfirmProtected(cfg config.DBConfig, action string, in io.Reader, out io.Writer) error {
+	if !cfg.Protected() {
+		return nil
+	}
+
+	label := cfg.Environment
+	if label == "" {
+		label = "read-only"
+	}
+	fmt.Fprintf(out, "%s is a %s configuration. Type the database name %q to %s it: ", cfg.ConfigName, label, cfg.Database, action)
+
+	typed, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return CheckConfirmation(cfg, typed)
+}
@@ -0,0 +1,326 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/charmbracelet/x/term"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/argon2"
+)
+
+// MasterKeyEnv holds the master passphrase for non-interactive use.
+const MasterKeyEnv = "ANYDB_MASTER_KEY"
+
+const (
+	encryptedPrefix = "enc:v1:"
+	keyCheckValue   = "anydb"
+	kdfArgon2id     = "argon2id"
+)
+
+var ErrWrongMasterKey = errors.New("wrong master key")
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptPassword returns the form of a password stored on disk. Empty and
+// already encrypted values are returned unchanged.
+func (s *YAMLStore) EncryptPassword(password string) (string, error) {
+	if password == "" || IsEncrypted(password) {
+		return password, nil
+	}
+	if err := s.Unlock(); err != nil {
+		return "", err
+	}
+	return encrypt(s.masterKey, password)
+}
+
+// DecryptPassword returns the plaintext of a stored password. Passwords
+// written before encryption was introduced are returned as they are, and
+// get encrypted on disk by the unlock.
+func (s *YAMLStore) DecryptPassword(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	if err := s.Unlock(); err != nil {
+		return "", err
+	}
+	return decrypt(s.masterKey, value)
+}
+
+// Unlock derives the master key from ANYDB_MASTER_KEY, the key file or an
+// interactive prompt. The first unlock creates the encryption header, and
+// every unlock encrypts passwords still stored in plaintext.
+func (s *YAMLStore) Unlock() error {
+	if s.masterKey != nil {
+		return nil
+	}
+
+	var header *config.EncryptionHeader
+	err := withFileLock(s.configFile, func() error {
+		fileData, err := s.readConfigFile()
+		header = fileData.Encryption
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The prompt runs without holding the lock, so other writers are not
+	// blocked while waiting for the passphrase.
+	var key []byte
+	created := header == nil
+	if created {
+		passphrase, err := s.masterPassphrase(true)
+		if err != nil {
+			return err
+		}
+		key, header, err = newEncryptionHeader(passphrase)
+		if err != nil {
+			return err
+		}
+	} else {
+		passphrase, err := s.masterPassphrase(false)
+		if err != nil {
+			return err
+		}
+		key, err = openEncryptionHeader(header, passphrase)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = withFileLock(s.configFile, func() error {
+		fileData, err := s.readConfigFile()
+		if err != nil {
+			return err
+		}
+		if created {
+			if fileData.Encryption != nil {
+				return fmt.Errorf("master key was set up concurrently, try again")
+			}
+			fileData.Encryption = header
+		}
+		return s.sealConfigFile(fileData, key, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.masterKey = key
+	return nil
+}
+
+// Rekey re-encrypts every stored password with a key derived from the new
+// passphrase.
+func (s *YAMLStore) Rekey(passphrase string) error {
+	if err := s.Unlock(); err != nil {
+		return err
+	}
+
+	key, header, err := newEncryptionHeader(passphrase)
+	if err != nil {
+		return err
+	}
+
+	err = withFileLock(s.configFile, func() error {
+		fileData, err := s.readConfigFile()
+		if err != nil {
+			return err
+		}
+		fileData.Encryption = header
+		return s.sealConfigFile(fileData, key, s.masterKey)
+	})
+	if err != nil {
+		return err
+	}
+	s.masterKey = key
+	return nil
+}
+
+// ReadNewPassphrase prompts for a new master passphrase twice.
+func ReadNewPassphrase() (string, error) {
+	passphrase, err := promptPassword("New master passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateNotEmpty(passphrase); err != nil {
+		return "", fmt.Errorf("master passphrase: %w", err)
+	}
+
+	confirm, err := promptPassword("Repeat master passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("master passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func (s *YAMLStore) masterPassphrase(create bool) (string, error) {
+	if passphrase := os.Getenv(MasterKeyEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	data, err := os.ReadFile(s.masterKeyFile)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		Log.Error("Failed to read master key file", zap.String("file", s.masterKeyFile), zap.Error(err))
+		return "", err
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("master key required: set %s or create %s", MasterKeyEnv, s.masterKeyFile)
+	}
+	if create {
+		fmt.Fprintln(os.Stderr, "Passwords are stored encrypted. Choose a master passphrase to protect them.")
+		return ReadNewPassphrase()
+	}
+	return promptPassword("Master passphrase: ")
+}
+
+func promptPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// sealConfigFile encrypts every password in the config file with key and
+// writes the file. Passwords encrypted
+// with oldKey are re-encrypted, so a nil oldKey only seals plaintext ones.
+// It must be called with the config file lock held.
+func (s *YAMLStore) sealConfigFile(fileData config.ConfigFileData, key, oldKey []byte) error {
+	seal := func(password string) (string, error) {
+		if password == "" || (IsEncrypted(password) && oldKey == nil) {
+			return password, nil
+		}
+		plaintext, err := decrypt(oldKey, password)
+		if err != nil {
+			return "", err
+		}
+		return encrypt(key, plaintext)
+	}
+
+	var err error
+	for i, cfg := range fileData.Configs {
+		fileData.Configs[i].Password, err = seal(cfg.Password)
+		if err != nil {
+			Log.Error("Failed to encrypt password", zap.String("configName", cfg.ConfigName), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := s.writeConfigFile(fileData); err != nil {
+		return err
+	}
+	s.sealPending = false
+	return nil
+}
+
+func newEncryptionHeader(passphrase string) ([]byte, *config.EncryptionHeader, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+
+	key := deriveKey(passphrase, salt)
+	check, err := encrypt(key, keyCheckValue)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, &config.EncryptionHeader{
+		KDF:   kdfArgon2id,
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Check: check,
+	}, nil
+}
+
+func openEncryptionHeader(header *config.EncryptionHeader, passphrase string) ([]byte, error) {
+	if header.KDF != kdfArgon2id {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", header.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(header.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %w", err)
+	}
+
+	key := deriveKey(passphrase, salt)
+	if check, err := decrypt(key, header.Check); err != nil || check != keyCheckValue {
+		return nil, ErrWrongMasterKey
+	}
+	return key, nil
+}
+
+func deriveKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32)
+}
+
+func encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrWrongMasterKey
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AnyoneClown/anydb/config"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := deriveKey("correct horse", []byte("0123456789abcdef"))
+	otherKey := deriveKey("battery staple", []byte("0123456789abcdef"))
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"ascii", "hunter2"},
+		{"empty", ""},
+		{"unicode", "pässwörd 🔑"},
+		{"looks encrypted", encryptedPrefix + "not really"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := encrypt(key, tt.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsEncrypted(sealed) || (tt.plaintext != "" && strings.Contains(sealed, tt.plaintext)) {
+				t.Fatalf("encrypt() = %q", sealed)
+			}
+			if again, _ := encrypt(key, tt.plaintext); again == sealed {
+				t.Errorf("encrypt() reused its nonce")
+			}
+
+			got, err := decrypt(key, sealed)
+			if err != nil || got != tt.plaintext {
+				t.Errorf("decrypt() = %q, %v, want %q", got, err, tt.plaintext)
+			}
+			if _, err := decrypt(otherKey, sealed); !errors.Is(err, ErrWrongMasterKey) {
+				t.Errorf("decrypt() with another key = %v, want %v", err, ErrWrongMasterKey)
+			}
+		})
+	}
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	key := deriveKey("correct horse", []byte("0123456789abcdef"))
+	if got, err := decrypt(key, "hunter2"); err != nil || got != "hunter2" {
+		t.Errorf("decrypt() = %q, %v, want the plaintext unchanged", got, err)
+	}
+	if _, err := decrypt(key, encryptedPrefix+"c2hvcnQ="); err == nil {
+		t.Errorf("decrypt() of a truncated value succeeded")
+	}
+}
+
+func TestOpenEncryptionHeader(t *testing.T) {
+	key, header, err := newEncryptionHeader("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		header     config.EncryptionHeader
+		passphrase string
+		wantErr    bool
+	}{
+		{"right passphrase", *header, "correct horse", false},
+		{"wrong passphrase", *header, "battery staple", true},
+		{"unknown kdf", config.EncryptionHeader{KDF: "md5", Salt: header.Salt, Check: header.Check}, "correct horse", true},
+		{"invalid salt", config.EncryptionHeader{KDF: header.KDF, Salt: "%%", Check: header.Check}, "correct horse", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openEncryptionHeader(&tt.header, tt.passphrase)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("openEncryptionHeader() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil || string(got) != string(key) {
+				t.Errorf("openEncryptionHeader() = %x, %v, want %x", got, err, key)
+			}
+		})
+	}
+}
+
+// TestRekey stores a password, rekeys the store and reads it back with the
+// new passphrase only.
+func TestRekey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(MasterKeyEnv, "correct horse")
+
+	s, err := NewYAMLStore(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := s.EncryptPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+		return append(configs, config.DBConfig{ConfigName: "prod", Driver: "postgres", Password: password}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Rekey("battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, "anydb-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), password) {
+		t.Fatalf("password was not re-encrypted:\n%s", data)
+	}
+
+	tests := []struct {
+		passphrase string
+		wantErr    error
+	}{
+		{"battery staple", nil},
+		{"correct horse", ErrWrongMasterKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.passphrase, func(t *testing.T) {
+			t.Setenv(MasterKeyEnv, tt.passphrase)
+			s, err := NewYAMLStore(home)
+			if err != nil {
+				t.Fatal(err)
+			}
+			configs, err := s.LoadConfigs()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.DecryptPassword(configs[0].Password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecryptPassword() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != "hunter2" {
+				t.Errorf("DecryptPassword() = %q, want hunter2", got)
+			}
+		})
+	}
+}
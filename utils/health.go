@@ -0,0 +1,59 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/jmoiron/sqlx"
+)
+
+// ConnectionReport is the outcome of a successful connection test.
+type ConnectionReport struct {
+	dialect.ServerInfo
+	// Latency is the round trip of a ping on the established connection.
+	Latency time.Duration
+}
+
+// TestConnection connects to cfg, pings it and reads the server info. The
+// error never contains the DSN, so it is safe to show.
+func TestConnection(ctx context.Context, store ConfigStore, cfg config.DBConfig) (ConnectionReport, error) {
+	d, err := dialect.Get(cfg.Driver)
+	if err != nil {
+		return ConnectionReport{}, err
+	}
+
+	dsn, err := configDSN(store, d, cfg)
+	if err != nil {
+		return ConnectionReport{}, err
+	}
+
+	db, err := sqlx.Open(d.DriverName(), dsn)
+	if err != nil {
+		return ConnectionReport{}, err
+	}
+	defer db.Close()
+
+	// The first ping opens the connection, the second one measures it.
+	if err := db.PingContext(ctx); err != nil {
+		return ConnectionReport{}, err
+	}
+	start := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		return ConnectionReport{}, err
+	}
+	report := ConnectionReport{Latency: time.Since(start)}
+
+	report.ServerInfo, err = d.ServerInfo(db)
+	if err != nil {
+		return ConnectionReport{}, err
+	}
+	return report, nil
+}
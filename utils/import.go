@@ -0,0 +1,315 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"go.uber.org/zap"
+)
+
+// ImportCandidate is a config found by an importer, along with where it was
+// found, such as ~/.pgpass:3.
+type ImportCandidate struct {
+	Config config.DBConfig
+	Origin string
+}
+
+// PgpassEntry is a line of a .pgpass file. Fields other than the password
+// may be *, which matches any value.
+type PgpassEntry struct {
+	Host, Port, Database, User, Password string
+	Origin                               string
+}
+
+// PgpassPassword returns the password of the first entry matching cfg, the
+// way libpq looks it up.
+func PgpassPassword(entries []PgpassEntry, cfg config.DBConfig) (string, bool) {
+	port := strconv.Itoa(cfg.Port)
+	for _, e := range entries {
+		if pgpassMatch(e.Host, cfg.Host) && pgpassMatch(e.Port, port) &&
+			pgpassMatch(e.Database, cfg.Database) && pgpassMatch(e.User, cfg.User) {
+			return e.Password, true
+		}
+	}
+	return "", false
+}
+
+func pgpassMatch(pattern, value string) bool {
+	return pattern == "*" || pattern == value
+}
+
+// ParsePgpass reads the host:port:database:user:password lines of a .pgpass
+// file. Colons and backslashes inside a field are escaped with a backslash.
+func ParsePgpass(r io.Reader, name string) ([]PgpassEntry, error) {
+	var entries []PgpassEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := splitPgpassLine(text)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("%s:%d: expected host:port:database:user:password", name, line)
+		}
+		entries = append(entries, PgpassEntry{
+			Host:     fields[0],
+			Port:     fields[1],
+			Database: fields[2],
+			User:     fields[3],
+			Password: fields[4],
+			Origin:   fmt.Sprintf("%s:%d", name, line),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+func splitPgpassLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':' && len(fields) < 4:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, field.String())
+}
+
+// PgpassCandidates turns the .pgpass entries naming a single server,
+// database and user into configs. Entries with wildcards only provide
+// passwords to the other importers.
+func PgpassCandidates(entries []PgpassEntry) []ImportCandidate {
+	var candidates []ImportCandidate
+	for _, e := range entries {
+		if e.Host == "*" || e.Database == "*" || e.User == "*" {
+			continue
+		}
+		port := dialect.DefaultPort("postgres")
+		if e.Port != "*" {
+			var err error
+			if port, err = strconv.Atoi(e.Port); err != nil {
+				continue
+			}
+		}
+		candidates = append(candidates, ImportCandidate{
+			Config: config.DBConfig{
+				ConfigName: e.Database + "@" + e.Host,
+				Driver:     "postgres",
+				Host:       e.Host,
+				Port:       port,
+				User:       e.User,
+				Password:   e.Password,
+				Database:   e.Database,
+			},
+			Origin: e.Origin,
+		})
+	}
+	return candidates
+}
+
+// pgServiceKeys maps the connection parameters of pg_service.conf and their
+// PG* environment variables to the config fields they set.
+var pgServiceKeys = map[string]func(cfg *config.DBConfig, value string) error{
+	"host":        func(cfg *config.DBConfig, v string) error { cfg.Host = v; return nil },
+	"user":        func(cfg *config.DBConfig, v string) error { cfg.User = v; return nil },
+	"password":    func(cfg *config.DBConfig, v string) error { cfg.Password = v; return nil },
+	"dbname":      func(cfg *config.DBConfig, v string) error { cfg.Database = v; return nil },
+	"sslmode":     func(cfg *config.DBConfig, v string) error { cfg.SSLMode = v; return nil },
+	"sslrootcert": func(cfg *config.DBConfig, v string) error { cfg.SSLRootCert = v; return nil },
+	"sslcert":     func(cfg *config.DBConfig, v string) error { cfg.SSLCert = v; return nil },
+	"sslkey":      func(cfg *config.DBConfig, v string) error { cfg.SSLKey = v; return nil },
+	"port": func(cfg *config.DBConfig, v string) error {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid port number %q", v)
+		}
+		cfg.Port = port
+		return nil
+	},
+}
+
+// ParsePgService reads the [service] sections of a pg_service.conf file,
+// each becoming a config named after its service. Unknown parameters are
+// ignored.
+func ParsePgService(r io.Reader, name string) ([]ImportCandidate, error) {
+	var candidates []ImportCandidate
+	var current *ImportCandidate
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			candidates = append(candidates, ImportCandidate{
+				Config: config.DBConfig{
+					ConfigName: strings.TrimSpace(text[1 : len(text)-1]),
+					Driver:     "postgres",
+					Port:       dialect.DefaultPort("postgres"),
+				},
+				Origin: fmt.Sprintf("%s:%d", name, line),
+			})
+			current = &candidates[len(candidates)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || current == nil {
+			return nil, fmt.Errorf("%s:%d: expected a [service] or key=value line", name, line)
+		}
+		if set, ok := pgServiceKeys[strings.TrimSpace(key)]; ok {
+			if err := set(&current.Config, strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+		}
+	}
+	return candidates, scanner.Err()
+}
+
+// pgEnvKeys are the PG* environment variables read by PGEnvCandidate.
+var pgEnvKeys = map[string]string{
+	"PGHOST":        "host",
+	"PGPORT":        "port",
+	"PGUSER":        "user",
+	"PGPASSWORD":    "password",
+	"PGDATABASE":    "dbname",
+	"PGSSLMODE":     "sslmode",
+	"PGSSLROOTCERT": "sslrootcert",
+	"PGSSLCERT":     "sslcert",
+	"PGSSLKEY":      "sslkey",
+}
+
+// PGEnvCandidate builds a config from the PG* environment variables read
+// through lookup. It returns false when neither PGHOST nor PGDATABASE is set.
+func PGEnvCandidate(lookup func(string) (string, bool)) (ImportCandidate, bool, error) {
+	_, hasHost := lookup("PGHOST")
+	_, hasDatabase := lookup("PGDATABASE")
+	if !hasHost && !hasDatabase {
+		return ImportCandidate{}, false, nil
+	}
+
+	cfg := config.DBConfig{
+		ConfigName: "pgenv",
+		Driver:     "postgres",
+		Host:       "localhost",
+		Port:       dialect.DefaultPort("postgres"),
+	}
+	for env, key := range pgEnvKeys {
+		if value, ok := lookup(env); ok && value != "" {
+			if err := pgServiceKeys[key](&cfg, value); err != nil {
+				return ImportCandidate{}, false, fmt.Errorf("%s: %w", env, err)
+			}
+		}
+	}
+	// libpq connects as the OS user and to the database of the same name by default
+	if cfg.User == "" {
+		cfg.User, _ = lookup("USER")
+	}
+	if cfg.Database == "" {
+		cfg.Database = cfg.User
+	}
+	return ImportCandidate{Config: cfg, Origin: "environment"}, true, nil
+}
+
+// ParseDotEnv reads the DATABASE_URL and *_DATABASE_URL variables of a .env
+// file. The configs are named after the directory of the file, followed by
+// the prefix of the variable. Variables holding an invalid URL are skipped.
+func ParseDotEnv(r io.Reader, name string) ([]ImportCandidate, error) {
+	project := filepath.Base(filepath.Dir(name))
+	if abs, err := filepath.Abs(name); err == nil {
+		project = filepath.Base(filepath.Dir(abs))
+	}
+
+	var candidates []ImportCandidate
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		text = strings.TrimPrefix(text, "export ")
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		if key != "DATABASE_URL" && !strings.HasSuffix(key, "_DATABASE_URL") {
+			continue
+		}
+		value = unquoteEnvValue(strings.TrimSpace(value))
+
+		// A bad URL is left out rather than failing the whole import
+		cfg, err := ParseConfigURL(value)
+		if err != nil {
+			Log.Warn("Skipping invalid connection URL", zap.String("origin", fmt.Sprintf("%s:%d", name, line)), zap.String("variable", key), zap.Error(err))
+			continue
+		}
+		cfg.ConfigName = project
+		if prefix := strings.TrimSuffix(key, "DATABASE_URL"); prefix != "" {
+			cfg.ConfigName += "-" + strings.ToLower(strings.TrimSuffix(prefix, "_"))
+		}
+		candidates = append(candidates, ImportCandidate{Config: cfg, Origin: fmt.Sprintf("%s:%d", name, line)})
+	}
+	return candidates, scanner.Err()
+}
+
+// unquoteEnvValue strips the quotes around a .env value and the comment
+// after it.
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// ImportFile runs parse on the named file. A missing file yields nothing,
+// since most importers read files that may not exist.
+func ImportFile(name string, parse func(io.Reader, string) ([]ImportCandidate, error)) ([]ImportCandidate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f, name)
+}
+
+// ConnectionKey identifies the database and user a config connects to, so
+// two configs with the same key are duplicates whatever their names.
+func ConnectionKey(cfg config.DBConfig) string {
+	if dialect.IsFileBased(cfg.Driver) {
+		if abs, err := filepath.Abs(cfg.Database); err == nil {
+			return cfg.Driver + ":" + abs
+		}
+		return cfg.Driver + ":" + cfg.Database
+	}
+	return fmt.Sprintf("%s://%s@%s:%d/%s", cfg.Driver, cfg.User, strings.ToLower(cfg.Host), cfg.Port, cfg.Database)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// withFileLock runs fn while holding an exclusive lock on <file>.lock. The
+// lock lives in its own file, so replacing file by rename keeps it intact.
+// It serializes the CLI and the web server as well as concurrent requests.
+func withFileLock(file string, fn func() error) error {
+	lock, err := os.OpenFile(file+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		Log.Error("Failed to open lock file", zap.String("file", file), zap.Error(err))
+		return err
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		Log.Error("Failed to lock configuration file", zap.String("file", file), zap.Error(err))
+		return err
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
+
+// writePrivateFile atomically replaces file with data, readable by the owner
+// only. Data goes to a temporary file that is synced and renamed over file,
+// so a crash mid-write leaves either the old or the new content.
+func writePrivateFile(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
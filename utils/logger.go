@@ -14,18 +14,19 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var Log *zap.Logger
+// Log discards everything until InitLogger is called.
+var Log = zap.NewNop()
 var logPath string
 
-func InitLogger() {
-	homeDir, _ := os.UserHomeDir()
-
-	logPath = filepath.Join(homeDir, ".anydb", "go.log")
+// InitLogger logs to stderr and to go.log in home, which must exist. Stdout
+// is kept for command output, so it can be piped into other tools.
+func InitLogger(home string) {
+	logPath = filepath.Join(home, "go.log")
 	os.OpenFile(logPath, os.O_RDONLY|os.O_CREATE, 0666)
 	os.Setenv("LOG_PATH", logPath)
 
 	config := zap.NewProductionConfig()
-	config.OutputPaths = []string{"stdout", logPath}
+	config.OutputPaths = []string{"stderr", logPath}
 	config.EncoderConfig.TimeKey = "timestamp"
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
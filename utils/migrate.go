@@ -0,0 +1,227 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// legacyDBConfig is the version 1 layout of a config, which stored the port
+// as a string.
+type legacyDBConfig struct {
+	ID         uuid.UUID `yaml:"id"`
+	ConfigName string    `yaml:"configName"`
+	Driver     string    `yaml:"driver"`
+	Host       string    `yaml:"host"`
+	Port       string    `yaml:"port"`
+	User       string    `yaml:"user"`
+	Password   string    `yaml:"password"`
+	Database   string    `yaml:"database"`
+
+	PasswordRef string `yaml:"passwordRef"`
+
+	SSLMode     string `yaml:"sslMode"`
+	SSLRootCert string `yaml:"sslRootCert"`
+	SSLCert     string `yaml:"sslCert"`
+	SSLKey      string `yaml:"sslKey"`
+}
+
+func (c legacyDBConfig) migrate() (config.DBConfig, error) {
+	var port int
+	if strings.TrimSpace(c.Port) != "" {
+		var err error
+		port, err = strconv.Atoi(strings.TrimSpace(c.Port))
+		if err != nil {
+			return config.DBConfig{}, fmt.Errorf("config %q has invalid port %q", c.ConfigName, c.Port)
+		}
+	}
+
+	return config.DBConfig{
+		ID:         c.ID,
+		ConfigName: c.ConfigName,
+		Driver:     strings.ToLower(c.Driver),
+		Host:       c.Host,
+		Port:       port,
+		User:       c.User,
+		Password:   c.Password,
+		Database:   c.Database,
+
+		PasswordRef: c.PasswordRef,
+
+		SSLMode:     c.SSLMode,
+		SSLRootCert: c.SSLRootCert,
+		SSLCert:     c.SSLCert,
+		SSLKey:      c.SSLKey,
+	}, nil
+}
+
+// configFileVersion detects the layout of the configuration file. Version 1
+// files are a bare YAML list and carry no version field.
+func configFileVersion(data []byte) (int, error) {
+	var node interface{}
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return 0, err
+	}
+
+	switch node.(type) {
+	case nil, []interface{}:
+		return 1, nil
+	}
+
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	if header.Version == 0 {
+		return 0, fmt.Errorf("configuration file has no version")
+	}
+	return header.Version, nil
+}
+
+// migrateConfigFile upgrades an older configuration file in place. The
+// original is kept next to it as <file>.v<version>.bak, without its
+// passwords. It must be called with the config file lock held.
+func (s *YAMLStore) migrateConfigFile(data []byte, version int) (config.ConfigFileData, error) {
+	var fileData config.ConfigFileData
+	if version == 1 {
+		var legacy []legacyDBConfig
+		if err := yaml.Unmarshal(data, &legacy); err != nil {
+			Log.Error("Failed to unmarshal legacy configuration data", zap.Error(err))
+			return config.ConfigFileData{}, err
+		}
+
+		for _, l := range legacy {
+			cfg, err := l.migrate()
+			if err != nil {
+				Log.Error("Failed to migrate configuration", zap.Error(err))
+				return config.ConfigFileData{}, err
+			}
+			fileData.Configs = append(fileData.Configs, cfg)
+		}
+	} else if err := yaml.Unmarshal(data, &fileData); err != nil {
+		Log.Error("Failed to unmarshal configuration data", zap.Error(err))
+		return config.ConfigFileData{}, err
+	}
+	fileData.Version = config.ConfigVersion
+
+	// An empty file has nothing worth backing up or rewriting yet.
+	if len(fileData.Configs) == 0 {
+		return fileData, nil
+	}
+
+	// Up to version 2 the default config was copied into its own file, it
+	// is now referenced by ID.
+	defaultID, err := s.legacyDefaultID()
+	if err != nil {
+		Log.Warn("Dropping unreadable default configuration file", zap.String("file", s.legacyDefaultFile), zap.Error(err))
+	}
+	fileData.Default = &defaultID
+	if _, ok := defaultConfig(fileData); !ok {
+		fileData.Default = nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", s.configFile, version)
+	backupData, err := stripPasswords(data)
+	if err == nil {
+		err = writePrivateFile(backup, backupData)
+	}
+	if err != nil {
+		Log.Error("Failed to back up configuration file", zap.String("backup", backup), zap.Error(err))
+		return config.ConfigFileData{}, err
+	}
+
+	// Plaintext passwords are encrypted right away once unlocked, and as soon
+	// as the lock is released otherwise, since unlocking may prompt.
+	if s.masterKey != nil {
+		err = s.sealConfigFile(fileData, s.masterKey, nil)
+	} else {
+		err = s.writeConfigFile(fileData)
+		s.sealPending = hasPlaintextPasswords(fileData)
+	}
+	if err != nil {
+		return config.ConfigFileData{}, err
+	}
+
+	// The copy may hold a plaintext password, so it is not kept around.
+	if err := os.Remove(s.legacyDefaultFile); err != nil && !os.IsNotExist(err) {
+		Log.Warn("Failed to remove default configuration file", zap.String("file", s.legacyDefaultFile), zap.Error(err))
+	}
+
+	Log.Info("Migrated configuration file",
+		zap.Int("from", version),
+		zap.Int("to", config.ConfigVersion),
+		zap.String("backup", backup),
+	)
+	return fileData, nil
+}
+
+// stripPasswords removes the plaintext passwords from the configuration
+// file data, whatever its version.
+func stripPasswords(data []byte) ([]byte, error) {
+	var node interface{}
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(stripPasswordNode(node))
+}
+
+func stripPasswordNode(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range n {
+			if password, ok := value.(string); ok && key == "password" && !IsEncrypted(password) {
+				delete(n, key)
+				continue
+			}
+			n[key] = stripPasswordNode(value)
+		}
+	case []interface{}:
+		for i, value := range n {
+			n[i] = stripPasswordNode(value)
+		}
+	}
+	return node
+}
+
+func hasPlaintextPasswords(fileData config.ConfigFileData) bool {
+	for _, cfg := range fileData.Configs {
+		if cfg.Password != "" && !IsEncrypted(cfg.Password) {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyDefaultID returns the ID of the config copied into the default
+// configuration file of version 2 and older, or uuid.Nil without one.
+func (s *YAMLStore) legacyDefaultID() (uuid.UUID, error) {
+	data, err := os.ReadFile(s.legacyDefaultFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, err
+	}
+
+	// The file may still hold a version 1 config, the legacy layout accepts
+	// both port types.
+	var legacy legacyDBConfig
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return uuid.Nil, err
+	}
+	return legacy.ID, nil
+}
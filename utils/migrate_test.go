@@ -0,0 +1,170 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AnyoneClown/anydb/config"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	migrateID  = "6f1c2b7e-0d5a-4c8e-9f3a-1b2c3d4e5f60"
+	migrateID2 = "0b9d7c1e-3f2a-4e5d-8c6b-7a8f9e0d1c2b"
+)
+
+func TestMigrateConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		file    string
+		// defaultFile is the copy of the default config of versions 1 and 2.
+		defaultFile string
+	}{
+		{
+			name:    "version 1",
+			version: 1,
+			file: `- id: ` + migrateID + `
+  configName: prod
+  driver: Postgres
+  host: db.example.com
+  port: "5432"
+  user: app
+  password: hunter2
+  database: app
+- id: ` + migrateID2 + `
+  configName: local
+  driver: sqlite
+  port: ""
+  database: /tmp/local.db
+`,
+			defaultFile: `id: ` + migrateID + `
+configName: prod
+driver: postgres
+host: db.example.com
+port: "5432"
+password: hunter2
+`,
+		},
+		{
+			name:    "version 2",
+			version: 2,
+			file: `version: 2
+configs:
+- id: ` + migrateID + `
+  configName: prod
+  driver: postgres
+  host: db.example.com
+  port: 5432
+  user: app
+  password: hunter2
+  database: app
+- id: ` + migrateID2 + `
+  configName: local
+  driver: sqlite
+  database: /tmp/local.db
+`,
+			defaultFile: `id: ` + migrateID + `
+configName: prod
+port: 5432
+password: hunter2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv(MasterKeyEnv, "correct horse")
+			configFile := filepath.Join(home, "anydb-config.yaml")
+			defaultFile := filepath.Join(home, "anydb-default-config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(defaultFile, []byte(tt.defaultFile), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := NewYAMLStore(home)
+			if err != nil {
+				t.Fatal(err)
+			}
+			configs, err := s.LoadConfigs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(configs) != 2 {
+				t.Fatalf("loaded %d configs, want 2", len(configs))
+			}
+			if prod := configs[0]; prod.Port != 5432 || prod.Driver != "postgres" {
+				t.Errorf("prod has driver %q and port %d, want postgres and 5432", prod.Driver, prod.Port)
+			}
+			if local := configs[1]; local.Port != 0 {
+				t.Errorf("local has port %d, want 0", local.Port)
+			}
+			if password, err := s.DecryptPassword(configs[0].Password); err != nil || password != "hunter2" {
+				t.Errorf("DecryptPassword() = %q, %v, want hunter2", password, err)
+			}
+
+			def, err := s.LoadDefaultConfig()
+			if err != nil || def.ID.String() != migrateID {
+				t.Errorf("LoadDefaultConfig() = %s, %v, want %s", def.ID, err, migrateID)
+			}
+
+			data, err := os.ReadFile(configFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fileData config.ConfigFileData
+			if err := yaml.Unmarshal(data, &fileData); err != nil {
+				t.Fatal(err)
+			}
+			if fileData.Version != config.ConfigVersion || fileData.Encryption == nil {
+				t.Errorf("file has version %d and encryption %v, want version %d and encryption",
+					fileData.Version, fileData.Encryption, config.ConfigVersion)
+			}
+
+			// No plaintext copy of the password may be left behind
+			backup := fmt.Sprintf("%s.v%d.bak", configFile, tt.version)
+			for _, name := range []string{configFile, backup} {
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(string(data), "hunter2") {
+					t.Errorf("%s holds the plaintext password:\n%s", filepath.Base(name), data)
+				}
+			}
+			if _, err := os.Stat(defaultFile); !os.IsNotExist(err) {
+				t.Errorf("default configuration file was not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigFileInvalidPort(t *testing.T) {
+	home := t.TempDir()
+	file := "- configName: prod\n  driver: postgres\n  port: fifty\n"
+	if err := os.WriteFile(filepath.Join(home, "anydb-config.yaml"), []byte(file), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewYAMLStore(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadConfigs(); err == nil {
+		t.Fatal("LoadConfigs() succeeded, want an invalid port error")
+	}
+	if _, err := os.Stat(filepath.Join(home, "anydb-config.yaml.v1.bak")); !os.IsNotExist(err) {
+		t.Errorf("backup written for a file that failed to migrate: %v", err)
+	}
+}
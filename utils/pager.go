@@ -0,0 +1,247 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/query"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// rowIDAlias names the hidden row identifier in the rows of a page, so it
+// does not clash with a column of the table.
+const rowIDAlias = "anydb_rowid"
+
+// Pager reads the rows of a table page by page. Pages are read by keyset on
+// the primary key, or on the hidden row identifier of the dialect, so every
+// page costs the same however deep it is. Tables with neither are read by
+// offset.
+type Pager struct {
+	db     *sqlx.DB
+	d      dialect.Dialect
+	schema string
+	table  string
+	limit  int
+
+	keys  []string
+	rowID bool
+	// columns describes the columns of the table by name, as the database
+	// does rather than the driver.
+	columns map[string]dialect.Column
+}
+
+// Page is a run of consecutive rows of a table.
+type Page struct {
+	query.ResultSet
+	// Offset is the position of the first row in the table, starting at 0.
+	Offset int
+
+	first, last []interface{}
+}
+
+// NewPager returns a pager reading limit rows at a time from table in schema.
+func NewPager(db *sqlx.DB, d dialect.Dialect, schema, table string, limit int) (*Pager, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("page size must be at least 1, got %d", limit)
+	}
+
+	keys, err := d.PrimaryKey(db, schema, table)
+	if err != nil {
+		Log.Error("Failed to get primary key", zap.String("schema", schema), zap.String("table", table), zap.Error(err))
+		return nil, err
+	}
+
+	described, err := d.DescribeColumns(db, schema, table)
+	if err != nil {
+		Log.Error("Failed to get table columns", zap.String("schema", schema), zap.String("table", table), zap.Error(err))
+		return nil, err
+	}
+
+	p := &Pager{db: db, d: d, schema: schema, table: table, limit: limit, keys: keys,
+		columns: make(map[string]dialect.Column, len(described))}
+	for _, column := range described {
+		p.columns[column.Name] = column
+	}
+	if r, ok := d.(dialect.RowIDer); ok && len(keys) == 0 {
+		// Views are read by offset
+		hasRowID, err := r.HasRowID(db, schema, table)
+		if err != nil {
+			Log.Warn("Failed to check for a row identifier, reading by offset", zap.String("table", table), zap.Error(err))
+		}
+		if hasRowID {
+			p.keys = []string{r.RowID()}
+			p.rowID = true
+		}
+	}
+	return p, nil
+}
+
+// Limit is the number of rows of a page.
+func (p *Pager) Limit() int {
+	return p.limit
+}
+
+// Order describes how the rows are ordered, for display.
+func (p *Pager) Order() string {
+	if len(p.keys) == 0 {
+		return "offset"
+	}
+	return "key " + strings.Join(p.keys, ", ")
+}
+
+// First reads the first page.
+func (p *Pager) First() (Page, error) {
+	return p.fetch(nil, false, 0)
+}
+
+// Last reads the last page of a table holding total rows.
+func (p *Pager) Last(total int) (Page, error) {
+	offset := total - p.limit
+	if offset < 0 {
+		offset = 0
+	}
+	return p.fetch(nil, true, offset)
+}
+
+// Next reads the page following page, which is empty at the end of the table.
+func (p *Pager) Next(page Page) (Page, error) {
+	offset := page.Offset + len(page.Rows)
+	if len(page.Rows) == 0 {
+		return Page{Offset: offset}, nil
+	}
+	return p.fetch(page.last, false, offset)
+}
+
+// Prev reads the page preceding page, which is empty at the start of the table.
+func (p *Pager) Prev(page Page) (Page, error) {
+	if page.Offset == 0 || len(page.Rows) == 0 {
+		return Page{}, nil
+	}
+	offset := page.Offset - p.limit
+	if offset < 0 {
+		offset = 0
+	}
+	return p.fetch(page.first, true, offset)
+}
+
+// Extend returns page followed by the rows of next, the page read after it.
+func (page Page) Extend(next Page) Page {
+	if len(next.Rows) == 0 {
+		return page
+	}
+	page.Rows = append(page.Rows, next.Rows...)
+	page.last = next.last
+	return page
+}
+
+// fetch reads the page after, or with backward before, the row with the
+// key values in cursor. Without keys the page is read at offset instead.
+func (p *Pager) fetch(cursor []interface{}, backward bool, offset int) (Page, error) {
+	statement, args, err := p.statement(cursor, backward, offset)
+	if err != nil {
+		return Page{}, err
+	}
+
+	rows, err := p.db.Queryx(statement, args...)
+	if err != nil {
+		Log.Error("Failed to execute query", zap.String("query", statement), zap.Error(err))
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	rs, err := query.Scan(rows)
+	if err != nil {
+		Log.Error("Failed to scan rows", zap.Error(err))
+		return Page{}, err
+	}
+
+	if backward && len(p.keys) > 0 {
+		slices.Reverse(rs.Rows)
+	}
+	page := Page{ResultSet: rs, Offset: offset}
+	if len(rs.Rows) > 0 {
+		page.first = p.key(rs, rs.Rows[0])
+		page.last = p.key(rs, rs.Rows[len(rs.Rows)-1])
+	}
+
+	// No row compares to NULL, so rows whose key holds one would be skipped
+	// or end the table. SQLite allows NULL in primary keys, such tables are
+	// read by offset instead.
+	if p.nullKey(rs) {
+		Log.Warn("Key holds NULL, reading by offset", zap.String("table", p.table), zap.Strings("keys", p.keys))
+		p.keys, p.rowID = nil, false
+		return p.fetch(nil, false, offset)
+	}
+
+	if p.rowID {
+		// The row identifier is only read to page on, it is not a column
+		page.Columns = page.Columns[1:]
+		for i, row := range page.Rows {
+			page.Rows[i] = row[1:]
+		}
+	}
+	for i, column := range page.Columns {
+		if described, ok := p.columns[column.Name]; ok {
+			page.Columns[i].Type = strings.ToLower(described.Type)
+			page.Columns[i].Nullable = described.Nullable
+		}
+	}
+	return page, nil
+}
+
+// statement builds the query of fetch.
+func (p *Pager) statement(cursor []interface{}, backward bool, offset int) (string, []interface{}, error) {
+	q := query.Select(p.d)
+	if p.rowID {
+		q.ColumnAs(p.keys[0], rowIDAlias)
+	}
+	q.AllColumns().From(p.schema, p.table)
+
+	if len(p.keys) == 0 {
+		q.Paginate(p.limit, offset)
+	} else {
+		if cursor != nil {
+			op := ">"
+			if backward {
+				op = "<"
+			}
+			q.WhereRow(p.keys, op, cursor)
+		}
+		for _, key := range p.keys {
+			q.OrderBy(key, backward)
+		}
+		q.Paginate(p.limit, 0)
+	}
+	return q.Build()
+}
+
+// key returns the key values of row, in the order of the keys.
+func (p *Pager) key(rs query.ResultSet, row query.Row) []interface{} {
+	if p.rowID {
+		return []interface{}{row[rs.Index(rowIDAlias)]}
+	}
+	values := make([]interface{}, len(p.keys))
+	for i, key := range p.keys {
+		values[i] = row[rs.Index(key)]
+	}
+	return values
+}
+
+// nullKey reports whether the key of a row of rs holds NULL.
+func (p *Pager) nullKey(rs query.ResultSet) bool {
+	for _, row := range rs.Rows {
+		if len(p.keys) > 0 && slices.Contains(p.key(rs, row), nil) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestPagerStatement(t *testing.T) {
+	postgres, _ := dialect.Get("postgres")
+	mysql, _ := dialect.Get("mysql")
+	sqlite, _ := dialect.Get("sqlite")
+
+	tests := []struct {
+		name     string
+		pager    Pager
+		cursor   []interface{}
+		backward bool
+		offset   int
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:  "first page by key",
+			pager: Pager{d: postgres, schema: "public", table: "users", limit: 10, keys: []string{"id"}},
+			want:  `SELECT * FROM "public"."users" ORDER BY "id" LIMIT 10 OFFSET 0`,
+		},
+		{
+			name:     "next page by key",
+			pager:    Pager{d: postgres, schema: "public", table: "users", limit: 10, keys: []string{"id"}},
+			cursor:   []interface{}{int64(42)},
+			offset:   10,
+			want:     `SELECT * FROM "public"."users" WHERE "id" > $1 ORDER BY "id" LIMIT 10 OFFSET 0`,
+			wantArgs: []interface{}{int64(42)},
+		},
+		{
+			name:     "previous page by composite key",
+			pager:    Pager{d: mysql, schema: "shop", table: "order", limit: 5, keys: []string{"tenant", "id"}},
+			cursor:   []interface{}{"acme", int64(7)},
+			backward: true,
+			want:     "SELECT * FROM `shop`.`order` WHERE (`tenant`, `id`) < (?, ?) ORDER BY `tenant` DESC, `id` DESC LIMIT 5 OFFSET 0",
+			wantArgs: []interface{}{"acme", int64(7)},
+		},
+		{
+			name:     "next page by row id",
+			pager:    Pager{d: sqlite, schema: "main", table: "events", limit: 3, keys: []string{"rowid"}, rowID: true},
+			cursor:   []interface{}{int64(3)},
+			want:     `SELECT "rowid" AS "anydb_rowid", * FROM "main"."events" WHERE "rowid" > ? ORDER BY "rowid" LIMIT 3 OFFSET 0`,
+			wantArgs: []interface{}{int64(3)},
+		},
+		{
+			name:   "page by offset without keys",
+			pager:  Pager{d: postgres, schema: "public", table: "log", limit: 20},
+			cursor: []interface{}{int64(1)},
+			offset: 60,
+			want:   `SELECT * FROM "public"."log" LIMIT 20 OFFSET 60`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := tt.pager.statement(tt.cursor, tt.backward, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("statement() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("statement() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestPagerSQLite pages through tables keyed on the primary key, on the
+// rowid and on a key holding NULL, and a view by offset.
+func TestPagerSQLite(t *testing.T) {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		t.Skipf("sqlite is not available: %v", err)
+	}
+	defer db.Close()
+	// Every connection to :memory: opens its own database
+	db.SetMaxOpenConns(1)
+
+	db.MustExec(`CREATE TABLE keyed (id INTEGER PRIMARY KEY, v TEXT)`)
+	db.MustExec(`CREATE TABLE plain (v TEXT)`)
+	db.MustExec(`CREATE TABLE nulls (k TEXT PRIMARY KEY, v TEXT)`)
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		db.MustExec(`INSERT INTO keyed (v) VALUES (?)`, v)
+		db.MustExec(`INSERT INTO plain (v) VALUES (?)`, v)
+	}
+	db.MustExec(`INSERT INTO nulls VALUES (NULL, 'a'), ('x', 'b'), (NULL, 'c'), ('y', 'd'), ('z', 'e')`)
+	db.MustExec(`CREATE VIEW joined AS SELECT k.v FROM keyed k JOIN plain p ON k.v = p.v`)
+
+	sqlite, _ := dialect.Get("sqlite")
+	tests := []struct {
+		table     string
+		wantOrder string
+	}{
+		{"keyed", "key id"},
+		{"plain", "key rowid"},
+		{"nulls", "offset"},
+		{"joined", "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			p, err := NewPager(db, sqlite, "main", tt.table, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var values []string
+			page, err := p.First()
+			for err == nil && len(page.Rows) > 0 {
+				for _, row := range page.Rows {
+					values = append(values, row[page.Index("v")].(string))
+				}
+				page, err = p.Next(page)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(values); got != 5 {
+				t.Errorf("read %d rows %v, want 5", got, values)
+			}
+			if got := p.Order(); got != tt.wantOrder {
+				t.Errorf("Order() = %q, want %q", got, tt.wantOrder)
+			}
+
+			last, err := p.Last(5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prev, err := p.Prev(last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(last.Rows) != 2 || len(prev.Rows) != 2 || prev.Offset != 1 {
+				t.Errorf("last page has %d rows and the one before %d at offset %d, want 2, 2 and 1",
+					len(last.Rows), len(prev.Rows), prev.Offset)
+			}
+			if len(page.Columns) > 0 && page.Index(rowIDAlias) >= 0 {
+				t.Errorf("page shows the hidden row id column")
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/secret"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectFileName is the project file looked up from the working directory.
+const ProjectFileName = ".anydb.yaml"
+
+var ErrProjectConfig = errors.New("configuration is declared in a project file")
+
+// FindProjectFile walks up from dir to the root looking for the project
+// file, like git looks for .git. It returns an empty path if there is none.
+func FindProjectFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		file := filepath.Join(dir, ProjectFileName)
+		info, err := os.Stat(file)
+		switch {
+		case err == nil && !info.IsDir():
+			return file, nil
+		case err != nil && !os.IsNotExist(err):
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readProjectFile reads the project file of the store, which is empty when
+// there is none. Project files are meant to be committed, so their configs
+// cannot hold a password and have to use a password reference instead,
+// to one of the allowedEnv variables or a file of the project.
+func (s *YAMLStore) readProjectFile(allowedEnv []string) (config.ProjectFileData, error) {
+	if s.projectFile == "" {
+		return config.ProjectFileData{}, nil
+	}
+
+	data, err := os.ReadFile(s.projectFile)
+	if err != nil {
+		Log.Error("Failed to read project file", zap.String("file", s.projectFile), zap.Error(err))
+		return config.ProjectFileData{}, err
+	}
+
+	var project config.ProjectFileData
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		Log.Error("Failed to unmarshal project file", zap.String("file", s.projectFile), zap.Error(err))
+		return config.ProjectFileData{}, fmt.Errorf("%s: %w", s.projectFile, err)
+	}
+
+	dir := filepath.Dir(s.projectFile)
+	for i, entry := range project.Configs {
+		if entry.Ref != "" {
+			continue
+		}
+		cfg := &project.Configs[i].DBConfig
+		if cfg.Password != "" {
+			return config.ProjectFileData{}, fmt.Errorf("%s: config %q stores a password, use passwordRef instead", s.projectFile, cfg.ConfigName)
+		}
+		if err := ValidateConfig(*cfg); err != nil {
+			return config.ProjectFileData{}, fmt.Errorf("%s: config %q: %w", s.projectFile, cfg.ConfigName, err)
+		}
+		cfg.PasswordRef, err = projectPasswordRef(dir, cfg.PasswordRef, allowedEnv)
+		if err != nil {
+			return config.ProjectFileData{}, fmt.Errorf("%s: config %q: %w", s.projectFile, cfg.ConfigName, err)
+		}
+
+		// IDs are optional in project files, a config without one gets an
+		// ID derived from the file and its name, which stays the same.
+		if cfg.ID == uuid.Nil {
+			cfg.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("file://"+s.projectFile+"#"+cfg.ConfigName))
+		}
+		cfg.Driver = strings.ToLower(cfg.Driver)
+		cfg.Source = s.projectFile
+
+		// Relative paths are relative to the project, not to the working directory
+		if dialect.IsFileBased(cfg.Driver) {
+			cfg.Database = projectPath(dir, cfg.Database)
+		}
+		cfg.SSLRootCert = projectPath(dir, cfg.SSLRootCert)
+		cfg.SSLCert = projectPath(dir, cfg.SSLCert)
+		cfg.SSLKey = projectPath(dir, cfg.SSLKey)
+	}
+
+	return project, nil
+}
+
+// projectPasswordRef checks a password reference of a project file, which
+// comes with the project rather than from the user. Any repository could
+// otherwise send a token of the environment or a file of the user to a
+// server of its choosing, so only the variables the user allowed and files
+// of the project can be referenced, and commands are rejected. Relative
+// files are made relative to the project.
+func projectPasswordRef(dir, ref string, allowedEnv []string) (string, error) {
+	switch scheme := secret.Scheme(ref); scheme {
+	case "":
+		return ref, nil
+	case "env":
+		if name := strings.TrimPrefix(ref, "env:"); !slices.Contains(allowedEnv, name) {
+			return "", fmt.Errorf("password reference %s is not allowed in project files, allow it with anydb configure allow-env %s", ref, name)
+		}
+		return ref, nil
+	case "file":
+		path := projectPath(dir, strings.TrimPrefix(ref, "file:"))
+		if !insideDir(dir, path) {
+			return "", fmt.Errorf("password reference %s is outside the project directory %s", ref, dir)
+		}
+		return "file:" + path, nil
+	default:
+		return "", fmt.Errorf("%s: password references are not allowed in project files, set it in a global config with anydb configure", scheme)
+	}
+}
+
+// insideDir reports whether path is inside dir, once symbolic links are
+// followed so a link in the project cannot point outside of it. A link
+// that cannot be followed yet is not trusted either.
+func insideDir(dir, path string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if _, err := os.Lstat(path); err == nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// AllowedEnv returns the environment variables project files and the web
+// API may reference as passwords.
+func (s *YAMLStore) AllowedEnv() ([]string, error) {
+	var fileData config.ConfigFileData
+	err := withFileLock(s.configFile, func() error {
+		var err error
+		fileData, err = s.readConfigFile()
+		return err
+	})
+	return fileData.AllowedEnv, err
+}
+
+// UpdateAllowedEnv runs fn on the environment variables project files and
+// the web API may reference and stores its result, sorted and without duplicates.
+func (s *YAMLStore) UpdateAllowedEnv(fn func([]string) ([]string, error)) error {
+	return s.updateConfigFile(func(fileData *config.ConfigFileData) error {
+		names, err := fn(fileData.AllowedEnv)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := ValidateAllowedEnv(name); err != nil {
+				return err
+			}
+		}
+		slices.Sort(names)
+		fileData.AllowedEnv = slices.Compact(names)
+		return nil
+	})
+}
+
+// ValidateAllowedEnv checks the name of a variable allowed in project
+// files and the web API. anydb's own variables, such as ANYDB_MASTER_KEY, are never allowed.
+func ValidateAllowedEnv(name string) error {
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	if strings.HasPrefix(strings.ToUpper(name), "ANYDB_") {
+		return fmt.Errorf("anydb variables such as %s cannot be referenced", name)
+	}
+	return nil
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CheckGlobalConfig rejects changes to configs of a project file, which is
+// edited by hand and usually committed with the project.
+func CheckGlobalConfig(cfg config.DBConfig) error {
+	if cfg.Source == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: edit %s instead", ErrProjectConfig, cfg.Source)
+}
+
+func projectPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// mergeProjectConfigs lists the project configs and the global configs they
+// reference first, followed by the other global configs. A project config
+// hides the global configs of the same name.
+func mergeProjectConfigs(global []config.DBConfig, project config.ProjectFileData) []config.DBConfig {
+	if len(project.Configs) == 0 {
+		return global
+	}
+
+	declared := make(map[string]bool)
+	for _, entry := range project.Configs {
+		if entry.Ref == "" {
+			declared[entry.ConfigName] = true
+		}
+	}
+
+	merged := make([]config.DBConfig, 0, len(global)+len(project.Configs))
+	listed := make(map[uuid.UUID]bool)
+	for _, entry := range project.Configs {
+		if entry.Ref == "" {
+			merged = append(merged, entry.DBConfig)
+			continue
+		}
+
+		found := false
+		for _, cfg := range global {
+			if cfg.ConfigName == entry.Ref && !listed[cfg.ID] {
+				merged = append(merged, cfg)
+				listed[cfg.ID] = true
+				found = true
+			}
+		}
+		if !found {
+			Log.Warn("Project file references an unknown configuration", zap.String("ref", entry.Ref))
+		}
+	}
+
+	for _, cfg := range global {
+		if !listed[cfg.ID] && !declared[cfg.ConfigName] {
+			merged = append(merged, cfg)
+		}
+	}
+	return merged
+}
+
+// projectDefault returns the config named as default by the project file,
+// looking at the project configs before the global ones.
+func projectDefault(global []config.DBConfig, project config.ProjectFileData) (config.DBConfig, bool, error) {
+	if project.Default == "" {
+		return config.DBConfig{}, false, nil
+	}
+	for _, entry := range project.Configs {
+		if entry.Ref == "" && (entry.ConfigName == project.Default || entry.ID.String() == project.Default) {
+			return entry.DBConfig, true, nil
+		}
+	}
+	for _, cfg := range global {
+		if cfg.ConfigName == project.Default || cfg.ID.String() == project.Default {
+			return cfg, true, nil
+		}
+	}
+	return config.DBConfig{}, false, fmt.Errorf("%w: project default %s", ErrConfigNotFound, project.Default)
+}
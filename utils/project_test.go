@@ -0,0 +1,53 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectPasswordRef(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	allowed := []string{"PGPASSWORD"}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", ref: "", want: ""},
+		{name: "allowed variable", ref: "env:PGPASSWORD", want: "env:PGPASSWORD"},
+		{name: "other variable", ref: "env:GITHUB_TOKEN", wantErr: true},
+		{name: "anydb variable", ref: "env:ANYDB_MASTER_KEY", wantErr: true},
+		{name: "project file", ref: "file:secrets/db", want: "file:" + filepath.Join(dir, "secrets/db")},
+		{name: "file outside", ref: "file:../secret", wantErr: true},
+		{name: "absolute file outside", ref: "file:" + filepath.Join(outside, "secret"), wantErr: true},
+		{name: "link outside", ref: "file:link", wantErr: true},
+		{name: "command", ref: "cmd:cat ~/.ssh/id_rsa", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := projectPasswordRef(dir, tt.ref, allowed)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("projectPasswordRef(%q) = %q, want an error", tt.ref, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("projectPasswordRef(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
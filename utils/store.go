@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Denys <https://github.com/AnyoneClown>
+This is my license. There are many like it, but this one is mine.
+My license is my best friend. It is my life. I must master it as I must
+master my life.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AnyoneClown/anydb/config"
+	"github.com/google/uuid"
+)
+
+// HomeEnv overrides the directory holding the anydb files, ~/.anydb by default.
+const HomeEnv = "ANYDB_HOME"
+
+// ConfigStore keeps the saved configs, the default config and the master key
+// protecting their passwords.
+type ConfigStore interface {
+	// Home is the directory holding the store files.
+	Home() string
+	// MasterKeyFile is the file read for the master passphrase before prompting.
+	MasterKeyFile() string
+	// ProjectFile is the project file merged into the configs, empty if none.
+	ProjectFile() string
+	// AllowedEnv lists the variables project files and the web API may
+	// reference as passwords.
+	AllowedEnv() ([]string, error)
+	UpdateAllowedEnv(fn func([]string) ([]string, error)) error
+
+	LoadConfigs() ([]config.DBConfig, error)
+	GetConfigByID(id uuid.UUID) (*config.DBConfig, error)
+	UpdateConfigs(fn func([]config.DBConfig) ([]config.DBConfig, error)) error
+
+	LoadDefaultConfig() (config.DBConfig, error)
+	SetDefaultConfig(id uuid.UUID) error
+	ClearDefaultConfig() error
+
+	Unlock() error
+	Rekey(passphrase string) error
+	EncryptPassword(password string) (string, error)
+	DecryptPassword(value string) (string, error)
+}
+
+// YAMLStore is the ConfigStore backed by YAML files in a home directory.
+type YAMLStore struct {
+	home          string
+	configFile    string
+	masterKeyFile string
+
+	// projectFile is the .anydb.yaml found from the working directory,
+	// whose configs and default are merged with those of configFile.
+	projectFile string
+
+	// legacyDefaultFile held a copy of the default config up to version 2
+	// of the configuration file, it is only read to migrate.
+	legacyDefaultFile string
+
+	// masterKey is the derived encryption key, cached once unlocked.
+	masterKey []byte
+
+	// sealPending is set when a migration wrote plaintext passwords, which
+	// are encrypted as soon as the lock is released.
+	sealPending bool
+}
+
+// NewYAMLStore opens the store in home, creating its files as needed.
+func NewYAMLStore(home string) (*YAMLStore, error) {
+	s := &YAMLStore{}
+	if err := s.Open(home); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open points the store at home, creating the directory and the config file
+// as needed, and looks up the project file from the working directory. It
+// does not log, so it can run before the logger is set up.
+func (s *YAMLStore) Open(home string) error {
+	s.home = home
+	s.configFile = filepath.Join(home, "anydb-config.yaml")
+	s.legacyDefaultFile = filepath.Join(home, "anydb-default-config.yaml")
+	s.masterKeyFile = filepath.Join(home, "anydb-master.key")
+	s.masterKey = nil
+
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return fmt.Errorf("failed to create home directory %s: %w", home, err)
+	}
+
+	f, err := os.OpenFile(s.configFile, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.configFile, err)
+	}
+	f.Close()
+
+	s.projectFile = ""
+	if cwd, err := os.Getwd(); err == nil {
+		s.projectFile, err = FindProjectFile(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to look up %s: %w", ProjectFileName, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *YAMLStore) Home() string {
+	return s.home
+}
+
+func (s *YAMLStore) MasterKeyFile() string {
+	return s.masterKeyFile
+}
+
+func (s *YAMLStore) ProjectFile() string {
+	return s.projectFile
+}
+
+// ResolveHome returns the home directory to use: home when set, otherwise
+// ANYDB_HOME, otherwise ~/.anydb.
+func ResolveHome(home string) (string, error) {
+	if home == "" {
+		home = os.Getenv(HomeEnv)
+	}
+	if home == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		home = filepath.Join(homeDir, ".anydb")
+	}
+	return filepath.Abs(home)
+}
@@ -7,10 +7,10 @@ master my life.
 package utils
 
 import (
-	"fmt"
-
 	"github.com/AnyoneClown/anydb/config"
-	"github.com/charmbracelet/bubbles/table"
+	"github.com/AnyoneClown/anydb/dialect"
+	"github.com/AnyoneClown/anydb/query"
+	"github.com/AnyoneClown/anydb/secret"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
@@ -20,94 +20,78 @@ type TableContent struct {
 	RowsCount int
 }
 
-func GetDBString() (string, error) {
-	err := LoadDefaultConfig()
+// GetDBString builds the DSN of cfg, resolving its password on the way.
+func GetDBString(store ConfigStore, cfg config.DBConfig) (string, error) {
+	d, err := dialect.Get(cfg.Driver)
 	if err != nil {
 		return "", err
 	}
-
-	var dsn string
-	switch config.DefaultConfigData.Driver {
-	case "cockroachdb":
-		dsn = fmt.Sprintf(
-			"postgresql://%s:%s@%s:%d/%s?sslmode=verify-full",
-			config.DefaultConfigData.User,
-			config.DefaultConfigData.Password,
-			config.DefaultConfigData.Host,
-			config.DefaultConfigData.Port,
-			config.DefaultConfigData.Database,
-		)
-	case "postgres":
-		dsn = fmt.Sprintf(
-			"postgresql://%s:%s@%s:%d/%s?sslmode=disable",
-			config.DefaultConfigData.User,
-			config.DefaultConfigData.Password,
-			config.DefaultConfigData.Host,
-			config.DefaultConfigData.Port,
-			config.DefaultConfigData.Database,
-		)
-	default:
-		return "", fmt.Errorf("unsupported database driver: %s", config.DefaultConfigData.Driver)
-	}
-	return dsn, nil
+	return configDSN(store, d, cfg)
 }
 
-func GetLastRecords(db *sqlx.DB, tableName string, limit int) ([]map[string]interface{}, error) {
-	query := fmt.Sprintf("SELECT * FROM %s ORDER BY id DESC LIMIT %d", tableName, limit)
-	rows, err := db.Queryx(query)
+// Connect opens a connection to cfg and returns it together with the
+// dialect of its driver.
+func Connect(store ConfigStore, cfg config.DBConfig) (*sqlx.DB, dialect.Dialect, error) {
+	d, err := dialect.Get(cfg.Driver)
 	if err != nil {
-		Log.Error("Failed to execute query", zap.String("query", query), zap.Error(err))
-		return nil, err
+		return nil, nil, err
 	}
-	defer rows.Close()
 
-	results := make([]map[string]interface{}, 0, limit)
-	for rows.Next() {
-		result := make(map[string]interface{})
-		if err := rows.MapScan(result); err != nil {
-			Log.Error("Failed to scan row", zap.Error(err))
-			return nil, err
-		}
-		results = append(results, result)
+	dsn, err := configDSN(store, d, cfg)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		Log.Error("Rows iteration error", zap.Error(err))
-		return nil, err
+	db, err := sqlx.Connect(d.DriverName(), dsn)
+	if err != nil {
+		return nil, nil, err
 	}
-	return results, nil
+	return db, d, nil
 }
 
-func GetTableColumns(db *sqlx.DB, tableName string) ([]table.Column, error) {
-	query := "SELECT column_name FROM information_schema.columns WHERE table_name = $1"
-	var columnNames []string
-	if err := db.Select(&columnNames, query, tableName); err != nil {
-		Log.Error("Failed to get table columns", zap.String("table", tableName), zap.Error(err))
-		return nil, err
+func configDSN(store ConfigStore, d dialect.Dialect, cfg config.DBConfig) (string, error) {
+	var err error
+	cfg.Password, err = ResolvePassword(store, cfg)
+	if err != nil {
+		return "", err
 	}
+	return d.DSN(cfg)
+}
 
-	columns := make([]table.Column, len(columnNames))
-	for i, name := range columnNames {
-		columns[i] = table.Column{Title: name, Width: 20}
+// ResolvePassword returns the plaintext password of cfg, resolving its
+// password reference or decrypting the stored password.
+func ResolvePassword(store ConfigStore, cfg config.DBConfig) (string, error) {
+	if cfg.PasswordRef != "" {
+		return secret.Resolve(cfg.PasswordRef)
 	}
+	return store.DecryptPassword(cfg.Password)
+}
 
-	return columns, nil
+// GetSchemas returns the schemas of the database holding user tables.
+func GetSchemas(db *sqlx.DB, d dialect.Dialect) ([]string, error) {
+	schemas, err := d.ListSchemas(db)
+	if err != nil {
+		Log.Error("Failed to get schemas", zap.Error(err))
+		return nil, err
+	}
+	return schemas, nil
 }
 
-func GetTables(db *sqlx.DB) ([]TableContent, error) {
-	var tableNames []string
+func GetTables(db *sqlx.DB, d dialect.Dialect, schema string) ([]TableContent, error) {
 	var tables []TableContent
 	var rows int
-	tableNamesQuery := "SELECT table_name FROM information_schema.tables WHERE table_schema = 'public'"
 
-	err := db.Select(&tableNames, tableNamesQuery)
+	tableNames, err := d.ListTables(db, schema)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, tableName := range tableNames {
-		rowsCountQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
-		err := db.QueryRow(rowsCountQuery).Scan(&rows)
+		rowsCountQuery, args, err := query.Select(d).Count().From(schema, tableName).Build()
+		if err != nil {
+			return nil, err
+		}
+		err = db.QueryRow(rowsCountQuery, args...).Scan(&rows)
 		if err != nil {
 			return nil, err
 		}
@@ -1,9 +1,13 @@
 package web
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AnyoneClown/anydb/config"
 	"github.com/AnyoneClown/anydb/utils"
@@ -11,32 +15,197 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v2"
 )
 
 // Handler struct to group all handler methods
-type Handler struct{}
+type Handler struct {
+	store utils.ConfigStore
+}
+
+// NewHandler returns a Handler serving the configs of store
+func NewHandler(store utils.ConfigStore) *Handler {
+	return &Handler{store: store}
+}
 
 // ConfigInput struct for binding JSON input
 type ConfigInput struct {
-	ConfigName string `json:"configName" binding:"required"`
-	Driver     string `json:"driver" binding:"required,oneof=postgres cockroachdb"`
-	Host       string `json:"host" binding:"required"`
-	Port       string `json:"port" binding:"required,port"`
-	User       string `json:"user" binding:"required"`
-	Password   string `json:"password" binding:"required"`
-	Database   string `json:"database" binding:"required"`
+	ConfigName string     `json:"configName" binding:"required"`
+	Driver     string     `json:"driver" binding:"required,driver"`
+	Host       string     `json:"host"`
+	Port       PortNumber `json:"port" binding:"omitempty,port"`
+	User       string     `json:"user"`
+	Password   string     `json:"password"`
+	Database   string     `json:"database" binding:"required"`
+
+	PasswordRef string `json:"passwordRef" binding:"secretref"`
+
+	SSLMode     string `json:"sslMode" binding:"sslmode"`
+	SSLRootCert string `json:"sslRootCert"`
+	SSLCert     string `json:"sslCert"`
+	SSLKey      string `json:"sslKey"`
+
+	Environment string   `json:"environment"`
+	Color       string   `json:"color" binding:"color"`
+	ReadOnly    FormBool `json:"readOnly"`
+}
+
+// PortNumber accepts a JSON number as well as the numeric string sent by html forms
+type PortNumber int
+
+func (p *PortNumber) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*p = 0
+		return nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid port number %s", data)
+	}
+	*p = PortNumber(port)
+	return nil
+}
+
+// FormBool accepts a JSON boolean as well as the checkbox values sent by html forms
+type FormBool bool
+
+func (b *FormBool) UnmarshalJSON(data []byte) error {
+	switch strings.ToLower(strings.Trim(string(data), `"`)) {
+	case "true", "on", "yes":
+		*b = true
+	case "false", "off", "no", "", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// Helper function to build the stored config from the input
+func (input ConfigInput) toDBConfig(id uuid.UUID) config.DBConfig {
+	return utils.NormalizeConfig(config.DBConfig{
+		ID:         id,
+		ConfigName: input.ConfigName,
+		Driver:     input.Driver,
+		Host:       input.Host,
+		Port:       int(input.Port),
+		User:       input.User,
+		Password:   input.Password,
+		Database:   input.Database,
+
+		PasswordRef: input.PasswordRef,
+
+		SSLMode:     input.SSLMode,
+		SSLRootCert: input.SSLRootCert,
+		SSLCert:     input.SSLCert,
+		SSLKey:      input.SSLKey,
+
+		Environment: input.Environment,
+		Color:       input.Color,
+		ReadOnly:    bool(input.ReadOnly),
+	})
 }
 
-// Custom validator for port
+// Custom validator for port, shared with the configure form
 func portValidator(fl validator.FieldLevel) bool {
-	port := fl.Field().String()
-	if p, err := strconv.Atoi(port); err == nil {
-		return p > 0 && p <= 65535
+	return utils.ValidatePortNumber(int(fl.Field().Int())) == nil
+}
+
+// Custom validator for driver, backed by the dialect registry
+func driverValidator(fl validator.FieldLevel) bool {
+	return utils.ValidateDatabaseDriver(fl.Field().String()) == nil
+}
+
+// Custom validator for sslmode, empty means the driver default
+func sslModeValidator(fl validator.FieldLevel) bool {
+	return utils.ValidateSSLMode(fl.Field().String()) == nil
+}
+
+// Custom validator for the syntax of password references such as
+// env:PGPASSWORD, the allowed variables are checked by the handlers
+func secretRefValidator(fl validator.FieldLevel) bool {
+	return utils.ValidatePasswordRef(fl.Field().String()) == nil
+}
+
+// Custom validator for the hex color of the environment label
+func colorValidator(fl validator.FieldLevel) bool {
+	return utils.ValidateColor(fl.Field().String()) == nil
+}
+
+// ConfigResponse is the config as returned by the API. Passwords are never
+// sent back, HasPassword tells whether one is stored. Revision matches the
+// ETag header and can be sent as If-Match on PUT and DELETE. Protected configs
+// also need the X-Confirm-Database header set to their database name there.
+type ConfigResponse struct {
+	ID          uuid.UUID
+	Revision    string
+	ConfigName  string
+	Driver      string
+	Host        string
+	Port        int
+	User        string
+	HasPassword bool
+	PasswordRef string
+	Database    string
+
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	Environment string
+	Color       string
+	ReadOnly    bool
+	Protected   bool
+
+	// Source is the project file declaring the config, such configs are
+	// read-only in the API.
+	Source string
+}
+
+// Helper function to build the redacted response for a config
+func newConfigResponse(cfg config.DBConfig) ConfigResponse {
+	return ConfigResponse{
+		ID:          cfg.ID,
+		Revision:    utils.ConfigRevision(cfg),
+		ConfigName:  cfg.ConfigName,
+		Driver:      cfg.Driver,
+		Host:        cfg.Host,
+		Port:        cfg.Port,
+		User:        cfg.User,
+		HasPassword: cfg.Password != "",
+		PasswordRef: cfg.PasswordRef,
+		Database:    cfg.Database,
+
+		SSLMode:     cfg.SSLMode,
+		SSLRootCert: cfg.SSLRootCert,
+		SSLCert:     cfg.SSLCert,
+		SSLKey:      cfg.SSLKey,
+
+		Environment: cfg.Environment,
+		Color:       cfg.EnvironmentColor(),
+		ReadOnly:    cfg.ReadOnly,
+		Protected:   cfg.Protected(),
+
+		Source: cfg.Source,
 	}
-	return false
 }
 
+// TestResponse is the outcome of a connection test. A failed connection is
+// reported with OK false and the error, not as an HTTP error.
+type TestResponse struct {
+	OK        bool
+	Error     string
+	LatencyMs float64
+	Version   string
+	User      string
+	TLS       string
+	ReadOnly  bool
+}
+
+// testTimeout bounds a connection test, so a black-holed host does not hang the request
+const testTimeout = 10 * time.Second
+
 // ErrorResponse struct for consistent error responses
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -54,14 +223,39 @@ func handleError(c *gin.Context, status int, err error, message string) {
 	c.JSON(status, ErrorResponse{Error: message})
 }
 
+// errInvalidConfig marks validation failures raised inside a store update
+var errInvalidConfig = errors.New("invalid configuration")
+
+// Helper function to map config store errors to their HTTP status
+func handleStoreError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, utils.ErrConfigNotFound):
+		handleError(c, http.StatusNotFound, err, "Configuration not found")
+	case errors.Is(err, utils.ErrRevisionMismatch):
+		handleError(c, http.StatusPreconditionFailed, err, "Configuration was modified, reload it and try again")
+	case errors.Is(err, utils.ErrProjectConfig):
+		handleError(c, http.StatusConflict, err, "Configuration is declared in a project file")
+	case errors.Is(err, utils.ErrNotConfirmed):
+		handleError(c, http.StatusPreconditionRequired, err, "Confirm the change by sending the database name in X-Confirm-Database")
+	case errors.Is(err, errInvalidConfig):
+		handleError(c, http.StatusBadRequest, err, "Invalid input")
+	default:
+		handleError(c, http.StatusInternalServerError, err, message)
+	}
+}
+
 // GET /api/configs
 func (h *Handler) GetConfigs(c *gin.Context) {
-	configs, err := utils.LoadConfigs(config.ConfigFile)
+	configs, err := h.store.LoadConfigs()
 	if err != nil {
 		handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
 		return
 	}
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Configurations retrieved successfully", Data: configs})
+	responses := make([]ConfigResponse, len(configs))
+	for i, cfg := range configs {
+		responses[i] = newConfigResponse(cfg)
+	}
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Configurations retrieved successfully", Data: responses})
 }
 
 // GET /api/configs/:id
@@ -72,13 +266,14 @@ func (h *Handler) GetConfig(c *gin.Context) {
 		return
 	}
 
-	config, err := utils.GetConfigByID(configID)
+	config, err := h.store.GetConfigByID(configID)
 	if err != nil {
 		handleError(c, http.StatusNotFound, err, "Configuration not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration retrieved successfully", Data: config})
+	c.Header("ETag", etag(*config))
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration retrieved successfully", Data: newConfigResponse(*config)})
 }
 
 // POST /api/configs
@@ -89,30 +284,33 @@ func (h *Handler) CreateConfig(c *gin.Context) {
 		return
 	}
 
-	newConfig := config.DBConfig{
-		ID:         uuid.New(),
-		ConfigName: input.ConfigName,
-		Driver:     input.Driver,
-		Host:       input.Host,
-		Port:       input.Port,
-		User:       input.User,
-		Password:   input.Password,
-		Database:   input.Database,
+	newConfig := input.toDBConfig(uuid.New())
+	if err := utils.ValidateConfig(newConfig); err != nil {
+		handleError(c, http.StatusBadRequest, err, "Invalid input")
+		return
+	}
+	if err := h.checkPasswordRef(newConfig.PasswordRef); err != nil {
+		handleError(c, http.StatusForbidden, err, "Password reference is not allowed")
+		return
 	}
 
-	configs, err := utils.LoadConfigs(config.ConfigFile)
+	var err error
+	newConfig.Password, err = h.store.EncryptPassword(newConfig.Password)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
+		handleError(c, http.StatusInternalServerError, err, "Failed to encrypt password")
 		return
 	}
 
-	configs = append(configs, newConfig)
-	if err := utils.SaveConfigs(configs, config.ConfigFile); err != nil {
+	err = h.store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+		return append(configs, newConfig), nil
+	})
+	if err != nil {
 		handleError(c, http.StatusInternalServerError, err, "Failed to save new configuration")
 		return
 	}
 
-	c.JSON(http.StatusCreated, SuccessResponse{Message: "Configuration created successfully", Data: newConfig})
+	c.Header("ETag", etag(newConfig))
+	c.JSON(http.StatusCreated, SuccessResponse{Message: "Configuration created successfully", Data: newConfigResponse(newConfig)})
 }
 
 // PUT /api/configs/:id
@@ -129,40 +327,50 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	configToUpdate, err := utils.GetConfigByID(configID)
-	if err != nil {
-		handleError(c, http.StatusNotFound, err, "Configuration not found")
+	if err := h.checkGlobalConfig(configID); err != nil {
+		handleStoreError(c, err, "Failed to load configuration")
+		return
+	}
+	if err := h.checkPasswordRef(input.PasswordRef); err != nil {
+		handleError(c, http.StatusForbidden, err, "Password reference is not allowed")
 		return
 	}
 
-	configs, err := utils.LoadConfigs(config.ConfigFile)
+	updatedConfig := input.toDBConfig(configID)
+	updatedConfig.Password, err = h.store.EncryptPassword(updatedConfig.Password)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
+		handleError(c, http.StatusInternalServerError, err, "Failed to encrypt password")
 		return
 	}
 
-	for i, cfg := range configs {
-		if cfg.ID == configToUpdate.ID {
-			configs[i] = config.DBConfig{
-				ID:         configID,
-				ConfigName: input.ConfigName,
-				Driver:     input.Driver,
-				Host:       input.Host,
-				Port:       input.Port,
-				User:       input.User,
-				Password:   input.Password,
-				Database:   input.Database,
-			}
-			break
+	err = h.store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+		i, err := findConfig(configs, configID, c.GetHeader("If-Match"))
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.CheckConfirmation(configs[i], c.GetHeader(confirmHeader)); err != nil {
+			return nil, err
+		}
+
+		// An omitted password keeps the stored one instead of clearing it
+		if input.Password == "" && input.PasswordRef == "" {
+			updatedConfig.Password = configs[i].Password
+			updatedConfig.PasswordRef = configs[i].PasswordRef
+		}
+		if err := utils.ValidateConfig(updatedConfig); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidConfig, err)
 		}
-	}
 
-	if err := utils.SaveConfigs(configs, config.ConfigFile); err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to save updated configuration")
+		configs[i] = updatedConfig
+		return configs, nil
+	})
+	if err != nil {
+		handleStoreError(c, err, "Failed to save updated configuration")
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration updated successfully"})
+	c.Header("ETag", etag(updatedConfig))
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration updated successfully", Data: newConfigResponse(updatedConfig)})
 }
 
 // DELETE /api/configs/:id
@@ -173,31 +381,72 @@ func (h *Handler) DeleteConfig(c *gin.Context) {
 		return
 	}
 
-	configToDelete, err := utils.GetConfigByID(configID)
-	if err != nil {
-		handleError(c, http.StatusNotFound, err, "Configuration not found")
+	if err := h.checkGlobalConfig(configID); err != nil {
+		handleStoreError(c, err, "Failed to load configuration")
 		return
 	}
 
-	configs, err := utils.LoadConfigs(config.ConfigFile)
+	err = h.store.UpdateConfigs(func(configs []config.DBConfig) ([]config.DBConfig, error) {
+		i, err := findConfig(configs, configID, c.GetHeader("If-Match"))
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.CheckConfirmation(configs[i], c.GetHeader(confirmHeader)); err != nil {
+			return nil, err
+		}
+		return append(configs[:i], configs[i+1:]...), nil
+	})
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
+		handleStoreError(c, err, "Failed to save updated configurations")
 		return
 	}
 
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration deleted successfully"})
+}
+
+// Helper function to reject password references the web API may not resolve
+func (h *Handler) checkPasswordRef(ref string) error {
+	if ref == "" {
+		return nil
+	}
+	allowedEnv, err := h.store.AllowedEnv()
+	if err != nil {
+		return err
+	}
+	return utils.ValidateWebPasswordRef(ref, allowedEnv)
+}
+
+// Helper function to reject changes to configs declared in a project file
+func (h *Handler) checkGlobalConfig(id uuid.UUID) error {
+	cfg, err := h.store.GetConfigByID(id)
+	if err != nil {
+		return err
+	}
+	return utils.CheckGlobalConfig(*cfg)
+}
+
+// Helper function to find a config for modification. A non-empty ifMatch
+// must equal the current ETag of the config, so writes based on a stale
+// copy are rejected instead of silently overwriting newer changes.
+func findConfig(configs []config.DBConfig, id uuid.UUID, ifMatch string) (int, error) {
 	for i, cfg := range configs {
-		if cfg.ID == configToDelete.ID {
-			configs = append(configs[:i], configs[i+1:]...)
-			break
+		if cfg.ID != id {
+			continue
+		}
+		if ifMatch != "" && ifMatch != "*" && ifMatch != etag(cfg) {
+			return 0, utils.ErrRevisionMismatch
 		}
+		return i, nil
 	}
+	return 0, fmt.Errorf("%w: %s", utils.ErrConfigNotFound, id)
+}
 
-	if err := utils.SaveConfigs(configs, config.ConfigFile); err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to save updated configurations")
-		return
-	}
+// confirmHeader carries the database name confirming a write to a protected config
+const confirmHeader = "X-Confirm-Database"
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration deleted successfully"})
+// Helper function to build the quoted ETag header of a config
+func etag(cfg config.DBConfig) string {
+	return `"` + utils.ConfigRevision(cfg) + `"`
 }
 
 // POST /api/configs/select/:id
@@ -208,7 +457,7 @@ func (h *Handler) SelectConfig(c *gin.Context) {
 		return
 	}
 
-	configs, err := utils.LoadConfigs(config.ConfigFile)
+	configs, err := h.store.LoadConfigs()
 	if err != nil {
 		handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
 		return
@@ -229,16 +478,55 @@ func (h *Handler) SelectConfig(c *gin.Context) {
 		return
 	}
 
-	data, err := yaml.Marshal(selectedConfig)
+	if err := utils.CheckGlobalConfig(selectedConfig); err != nil {
+		handleStoreError(c, err, "Failed to save default configuration")
+		return
+	}
+	if err := h.store.SetDefaultConfig(selectedConfig.ID); err != nil {
+		handleStoreError(c, err, "Failed to save default configuration")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration selected successfully", Data: newConfigResponse(selectedConfig)})
+}
+
+// POST /api/configs/:id/test
+func (h *Handler) TestConfig(c *gin.Context) {
+	configID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err, "Invalid UUID format")
+		return
+	}
+
+	cfg, err := h.store.GetConfigByID(configID)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to marshal selected config")
+		handleStoreError(c, err, "Failed to load configuration")
 		return
 	}
 
-	if err := os.WriteFile(config.DefaultConfigFile, data, 0644); err != nil {
-		handleError(c, http.StatusInternalServerError, err, "Failed to write default config file")
+	// File and command references, and variables that were not allowed,
+	// are only resolved from the command line
+	if err := h.checkPasswordRef(cfg.PasswordRef); err != nil {
+		handleError(c, http.StatusForbidden, err, "Password reference cannot be resolved from the web API, test it with anydb configure test")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), testTimeout)
+	defer cancel()
+
+	report, err := utils.TestConnection(ctx, h.store, *cfg)
+	if err != nil {
+		utils.Log.Warn("Connection test failed", zap.String("configName", cfg.ConfigName), zap.Error(err))
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Connection test failed", Data: TestResponse{Error: err.Error()}})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Configuration selected successfully", Data: selectedConfig})
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Connection test succeeded", Data: TestResponse{
+		OK:        true,
+		LatencyMs: float64(report.Latency.Microseconds()) / 1000,
+		Version:   report.Version,
+		User:      report.User,
+		TLS:       report.TLS,
+		ReadOnly:  report.ReadOnly,
+	}})
 }
@@ -7,10 +7,11 @@ master my life.
 package web
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"time"
 
-	"github.com/AnyoneClown/anydb/config"
 	"github.com/AnyoneClown/anydb/utils"
 	"github.com/AnyoneClown/anydb/web/gintemplrenderer"
 	"github.com/AnyoneClown/anydb/web/templates"
@@ -18,9 +19,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
 )
 
-func Web() {
+// Web serves the web interface on addr. It has no authentication, so a
+// warning is logged when addr is not a loopback address. With checkHost,
+// API requests naming another host are rejected, so a page that rebinds its
+// own domain to 127.0.0.1 cannot reach the API from the browser.
+func Web(store utils.ConfigStore, addr string, checkHost bool) {
+	// Unlock up front, so saving a password never waits on a prompt mid-request.
+	if err := store.Unlock(); err != nil {
+		utils.Log.Fatal("Failed to unlock stored passwords", zap.Error(err))
+	}
+
 	engine := gin.Default()
 
 	ginHtmlRenderer := engine.HTMLRender
@@ -36,24 +47,69 @@ func Web() {
 	// Custom validator
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("port", portValidator)
+		v.RegisterValidation("driver", driverValidator)
+		v.RegisterValidation("sslmode", sslModeValidator)
+		v.RegisterValidation("secretref", secretRefValidator)
+		v.RegisterValidation("color", colorValidator)
 	}
 
 	// Main Page
 	engine.GET("/", func(c *gin.Context) {
-		r := gintemplrenderer.New(c.Request.Context(), http.StatusOK, templates.DBConfigView(config.Configs))
+		configs, err := store.LoadConfigs()
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, err, "Failed to load existing configurations")
+			return
+		}
+		r := gintemplrenderer.New(c.Request.Context(), http.StatusOK, templates.DBConfigView(configs))
 		c.Render(http.StatusOK, r)
 	})
 
 	// API for configs
 	api := engine.Group("/api")
+	if checkHost {
+		api.Use(loopbackHost)
+	}
 	{
-		handler := &Handler{}
+		handler := NewHandler(store)
 		api.GET("/configs", handler.GetConfigs)
 		api.GET("/configs/:id", handler.GetConfig)
 		api.POST("/configs", handler.CreateConfig)
 		api.DELETE("/configs/:id", handler.DeleteConfig)
 		api.PUT("/configs/:id", handler.UpdateConfig)
 		api.POST("/configs/select/:id", handler.SelectConfig)
+		api.POST("/configs/:id/test", handler.TestConfig)
+	}
+	if !isLoopback(addr) {
+		utils.Log.Warn("The web interface has no authentication, anyone reaching this address can change the configurations", zap.String("listen", addr))
+	}
+	if err := engine.Run(addr); err != nil {
+		utils.Log.Fatal("Failed to serve the web interface", zap.Error(err))
+	}
+}
+
+// loopbackHost rejects requests whose Host header is not a loopback address.
+func loopbackHost(c *gin.Context) {
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if !isLoopback(net.JoinHostPort(host, "0")) {
+		handleError(c, http.StatusForbidden, fmt.Errorf("host %s is not a loopback address", c.Request.Host), "Requests must be sent to localhost")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// isLoopback reports whether addr only accepts connections from this host.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
 	}
-	engine.Run(":8080")
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
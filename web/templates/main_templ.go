@@ -1,6 +1,6 @@
 // Code generated by templ - DO NOT EDIT.
 
-// templ: version: v0.2.747
+// templ: version: v0.2.778
 package templates
 
 //lint:file-ignore SA4006 This context is only used if a nested component is present.
@@ -13,6 +13,9 @@ import "github.com/AnyoneClown/anydb/config"
 func DBConfigView(configs []config.DBConfig) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
 		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 		if !templ_7745c5c3_IsBuffer {
 			defer func() {
@@ -28,10 +31,12 @@ func DBConfigView(configs []config.DBConfig) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<!doctype html><html><head><script src=\"https://unpkg.com/htmx.org@1.9.10\" integrity=\"sha384-D1Kt99CQMDuVetoL1lrYwg5t+9QdHe7NLX/SoJYkXDFfX37iInKRy5xLSi8nO7UC\" crossorigin=\"anonymous\"></script><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://unpkg.com/htmx.org/dist/ext/json-enc.js\"></script><title>DB Configurations</title><style>\n            @keyframes fadeIn {\n                from { opacity: 0; transform: translateY(10px); }\n                to { opacity: 1; transform: translateY(0); }\n            }\n            \n            .animate-fade-in {\n                animation: fadeIn 0.3s ease-out forwards;\n            }\n            \n            tr.htmx-swapping td {\n                opacity: 0;\n                transition: opacity 0.3s ease-out;\n            }\n            \n            .input-focus-effect:focus {\n                box-shadow: 0 0 0 2px rgba(34, 197, 94, 0.2);\n                border-color: rgb(34, 197, 94);\n            }\n            \n            .gradient-background {\n                background: linear-gradient(135deg, rgb(17, 24, 39) 0%, rgb(75, 85, 99) 100%);\n            }\n\n            /* Стилі для скролбару */\n            .custom-scrollbar::-webkit-scrollbar {\n                height: 8px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-track {\n                background: rgba(75, 85, 99, 0.1);\n                border-radius: 4px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-thumb {\n                background: rgba(75, 85, 99, 0.5);\n                border-radius: 4px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-thumb:hover {\n                background: rgba(75, 85, 99, 0.7);\n            }\n        </style></head><body class=\"gradient-background min-h-screen\"><div class=\"min-h-screen flex flex-col items-center justify-start py-6 px-2 sm:px-4 lg:px-6\"><div class=\"bg-gray-800 shadow-2xl rounded-xl p-4 sm:p-6 w-full max-w-[98%] border border-gray-700\"><div class=\"space-y-2 mb-6\"><h1 class=\"text-2xl sm:text-3xl font-bold bg-gradient-to-r from-green-400 to-emerald-500 bg-clip-text text-transparent\">DB Configurations</h1><p class=\"text-gray-400\">Manage your database configurations securely in one place</p></div><form class=\"space-y-4 mb-6\" hx-post=\"/api/configs\" hx-target=\"#configTable\" hx-swap=\"outerHTML\" hx-ext=\"json-enc\"><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-4\"><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"configName\">Config Name</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"configName\" placeholder=\"Enter config name\" name=\"configName\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"driver\">Driver</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"driver\" placeholder=\"postgres or cockroachdb\" name=\"driver\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"host\">Host</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"host\" placeholder=\"Enter host\" name=\"host\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"port\">Port</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"port\" placeholder=\"Enter port\" name=\"port\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"user\">User</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"user\" placeholder=\"Enter user\" name=\"user\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"password\">Password</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" type=\"password\" id=\"password\" placeholder=\"Enter password\" name=\"password\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"database\">Database</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"database\" placeholder=\"Enter database\" name=\"database\" required></div></div><button class=\"w-full sm:w-auto px-6 py-2 rounded-lg bg-gradient-to-r from-green-500 to-emerald-600 text-white font-medium hover:from-green-600 hover:to-emerald-700 transition-all duration-200 shadow-lg hover:shadow-xl transform hover:-translate-y-0.5\" type=\"submit\">Add Configuration</button></form><div class=\"overflow-x-auto custom-scrollbar rounded-xl shadow-xl border border-gray-700\"><div id=\"configTable\" class=\"min-w-full\"><table class=\"min-w-full\"><thead class=\"bg-gray-900\"><tr><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Config Name</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Driver</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Host</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Port</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">User</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Database</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Actions</th></tr></thead> <tbody class=\"bg-gray-800 divide-y divide-gray-700\" id=\"configRows\"></tbody></table></div></div></div></div><script>\n            document.addEventListener('DOMContentLoaded', function() {\n                loadConfigs();\n                setInterval(loadConfigs, 30000);\n            });\n\n            function loadConfigs() {\n                fetch('/api/configs')\n                    .then(response => response.json())\n                    .then(data => {\n                        const tbody = document.getElementById('configRows');\n                        tbody.innerHTML = '';\n\n                        data.data.forEach((config, index) => {\n                            const tr = document.createElement('tr');\n                            tr.className = 'hover:bg-gray-700 transition-colors animate-fade-in';\n                            tr.style.animationDelay = `${index * 50}ms`;\n                            \n                            tr.innerHTML = `\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.ConfigName}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.Driver}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.Host}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.Port}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.User}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    ${config.Database}\n                                </td>\n                                <td class=\"px-4 py-3 whitespace-nowrap text-sm text-gray-300\">\n                                    <button \n                                        onclick=\"deleteConfig('${config.ID}')\"\n                                        class=\"text-red-400 hover:text-red-300 transition-colors px-3 py-1 rounded-md hover:bg-red-500/10\"\n                                    >\n                                        Delete\n                                    </button>\n                                </td>\n                            `;\n                            \n                            tbody.appendChild(tr);\n                        });\n                    })\n                    .catch(error => console.error('Error loading configs:', error));\n            }\n\n            function deleteConfig(id) {\n                if (confirm('Are you sure you want to delete this configuration?')) {\n                    fetch(`/api/configs/${id}`, {\n                        method: 'DELETE'\n                    })\n                    .then(response => {\n                        if (response.ok) {\n                            loadConfigs();\n                        } else {\n                            alert('Error deleting configuration');\n                        }\n                    })\n                    .catch(error => console.error('Error:', error));\n                }\n            }\n        </script></body></html>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<!doctype html><html><head><script src=\"https://unpkg.com/htmx.org@1.9.10\" integrity=\"sha384-D1Kt99CQMDuVetoL1lrYwg5t+9QdHe7NLX/SoJYkXDFfX37iInKRy5xLSi8nO7UC\" crossorigin=\"anonymous\"></script><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://unpkg.com/htmx.org/dist/ext/json-enc.js\"></script><title>DB Configurations</title><style>\n            @keyframes fadeIn {\n                from { opacity: 0; transform: translateY(10px); }\n                to { opacity: 1; transform: translateY(0); }\n            }\n            \n            .animate-fade-in {\n                animation: fadeIn 0.3s ease-out forwards;\n            }\n            \n            tr.htmx-swapping td {\n                opacity: 0;\n                transition: opacity 0.3s ease-out;\n            }\n            \n            .input-focus-effect:focus {\n                box-shadow: 0 0 0 2px rgba(34, 197, 94, 0.2);\n                border-color: rgb(34, 197, 94);\n            }\n            \n            .gradient-background {\n                background: linear-gradient(135deg, rgb(17, 24, 39) 0%, rgb(75, 85, 99) 100%);\n            }\n\n            /* Стилі для скролбару */\n            .custom-scrollbar::-webkit-scrollbar {\n                height: 8px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-track {\n                background: rgba(75, 85, 99, 0.1);\n                border-radius: 4px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-thumb {\n                background: rgba(75, 85, 99, 0.5);\n                border-radius: 4px;\n            }\n            \n            .custom-scrollbar::-webkit-scrollbar-thumb:hover {\n                background: rgba(75, 85, 99, 0.7);\n            }\n        </style></head><body class=\"gradient-background min-h-screen\"><div class=\"min-h-screen flex flex-col items-center justify-start py-6 px-2 sm:px-4 lg:px-6\"><div class=\"bg-gray-800 shadow-2xl rounded-xl p-4 sm:p-6 w-full max-w-[98%] border border-gray-700\"><div class=\"space-y-2 mb-6\"><h1 class=\"text-2xl sm:text-3xl font-bold bg-gradient-to-r from-green-400 to-emerald-500 bg-clip-text text-transparent\">DB Configurations</h1><p class=\"text-gray-400\">Manage your database configurations securely in one place</p></div><form class=\"space-y-4 mb-6\" hx-post=\"/api/configs\" hx-target=\"#configTable\" hx-swap=\"outerHTML\" hx-ext=\"json-enc\"><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-4\"><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"configName\">Config Name</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"configName\" placeholder=\"Enter config name\" name=\"configName\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"driver\">Driver</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"driver\" placeholder=\"postgres, cockroachdb, mysql, mariadb or sqlite\" name=\"driver\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"host\">Host</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"host\" placeholder=\"Enter host\" name=\"host\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"port\">Port</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"port\" placeholder=\"Enter port\" name=\"port\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"user\">User</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"user\" placeholder=\"Enter user\" name=\"user\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"password\">Password</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" type=\"password\" id=\"password\" placeholder=\"Enter password\" name=\"password\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"passwordRef\">Password Reference</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"passwordRef\" placeholder=\"env:VARIABLE (optional)\" name=\"passwordRef\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"database\">Database</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"database\" placeholder=\"Enter database or sqlite file path\" name=\"database\" required></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"sslMode\">SSL Mode</label> <select class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"sslMode\" name=\"sslMode\"><option value=\"\">Driver default</option> <option value=\"disable\">disable</option> <option value=\"require\">require</option> <option value=\"verify-ca\">verify-ca</option> <option value=\"verify-full\">verify-full</option></select></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"sslRootCert\">SSL Root CA</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"sslRootCert\" placeholder=\"Path to root CA (optional)\" name=\"sslRootCert\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"sslCert\">SSL Client Cert</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"sslCert\" placeholder=\"Path to client cert (optional)\" name=\"sslCert\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"sslKey\">SSL Client Key</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"sslKey\" placeholder=\"Path to client key (optional)\" name=\"sslKey\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"environment\">Environment</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"environment\" placeholder=\"dev, staging or prod (optional)\" name=\"environment\"></div><div class=\"space-y-1\"><label class=\"block text-sm font-medium text-gray-300\" for=\"color\">Color</label> <input class=\"w-full px-3 py-2 rounded-lg bg-gray-700 border border-gray-600 text-gray-100 placeholder-gray-400 input-focus-effect transition-all duration-200\" id=\"color\" placeholder=\"#dc2626 (optional)\" name=\"color\"></div><div class=\"space-y-1 flex items-end\"><label class=\"flex items-center gap-2 text-sm font-medium text-gray-300 py-2\" for=\"readOnly\"><input class=\"rounded bg-gray-700 border border-gray-600\" type=\"checkbox\" id=\"readOnly\" name=\"readOnly\"> Read-only sessions</label></div></div><button class=\"w-full sm:w-auto px-6 py-2 rounded-lg bg-gradient-to-r from-green-500 to-emerald-600 text-white font-medium hover:from-green-600 hover:to-emerald-700 transition-all duration-200 shadow-lg hover:shadow-xl transform hover:-translate-y-0.5\" type=\"submit\">Add Configuration</button></form><div class=\"overflow-x-auto custom-scrollbar rounded-xl shadow-xl border border-gray-700\"><div id=\"configTable\" class=\"min-w-full\"><table class=\"min-w-full\"><thead class=\"bg-gray-900\"><tr><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Config Name</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Environment</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Driver</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Host</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Port</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">User</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Database</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">SSL Mode</th><th scope=\"col\" class=\"px-4 py-3 text-left text-xs font-medium text-gray-300 uppercase tracking-wider whitespace-nowrap\">Actions</th></tr></thead> <tbody class=\"bg-gray-800 divide-y divide-gray-700\" id=\"configRows\"></tbody></table></div></div></div></div><script>\n            // configs holds the configurations last loaded.\n            let configs = [];\n\n            document.addEventListener('DOMContentLoaded', function() {\n                loadConfigs();\n                setInterval(loadConfigs, 30000);\n            });\n\n            function loadConfigs() {\n                fetch('/api/configs')\n                    .then(response => response.json())\n                    .then(data => {\n                        const tbody = document.getElementById('configRows');\n                        tbody.innerHTML = '';\n                        configs = data.data;\n\n                        data.data.forEach((config, index) => {\n                            const tr = document.createElement('tr');\n                            tr.className = 'hover:bg-gray-700 transition-colors animate-fade-in';\n                            tr.style.animationDelay = `${index * 50}ms`;\n                            \n                            // Every value is set as text, configs come from project files and imports\n                            [\n                                config.ConfigName,\n                                environmentBadge(config),\n                                config.Driver,\n                                config.Host,\n                                config.Port,\n                                config.User,\n                                config.Database,\n                                config.SSLMode || 'default',\n                            ].forEach(value => tr.appendChild(tableCell(value)));\n\n                            const actions = tableCell('');\n                            actions.appendChild(actionButton('Test', 'text-green-400 hover:text-green-300 hover:bg-green-500/10', button => testConfig(config.ID, button)));\n                            actions.appendChild(actionButton('Delete', 'text-red-400 hover:text-red-300 hover:bg-red-500/10', () => deleteConfig(config.ID)));\n                            tr.appendChild(actions);\n                            \n                            tbody.appendChild(tr);\n                        });\n                    })\n                    .catch(error => console.error('Error loading configs:', error));\n            }\n\n            // tableCell returns a cell holding value, a node or text.\n            function tableCell(value) {\n                const td = document.createElement('td');\n                td.className = 'px-4 py-3 whitespace-nowrap text-sm text-gray-300';\n                if (value instanceof Node) {\n                    td.appendChild(value);\n                } else {\n                    td.textContent = value;\n                }\n                return td;\n            }\n\n            function actionButton(label, colors, onClick) {\n                const button = document.createElement('button');\n                button.className = `${colors} transition-colors px-3 py-1 rounded-md`;\n                button.textContent = label;\n                button.addEventListener('click', () => onClick(button));\n                return button;\n            }\n\n            function testConfig(id, button) {\n                button.disabled = true;\n                button.textContent = 'Testing...';\n                fetch(`/api/configs/${id}/test`, { method: 'POST' })\n                    .then(response => response.json())\n                    .then(data => {\n                        const result = data.data;\n                        if (!result) {\n                            alert(data.error || 'Error testing configuration');\n                        } else if (result.OK) {\n                            alert(`Connected in ${result.LatencyMs} ms\\n` +\n                                `Version: ${result.Version}\\n` +\n                                `User: ${result.User || '-'}\\n` +\n                                `TLS: ${result.TLS || 'off'}\\n` +\n                                `Read only: ${result.ReadOnly ? 'yes' : 'no'}`);\n                        } else {\n                            alert(`Connection failed: ${result.Error}`);\n                        }\n                    })\n                    .catch(error => console.error('Error:', error))\n                    .finally(() => {\n                        button.disabled = false;\n                        button.textContent = 'Test';\n                    });\n            }\n\n            function environmentBadge(config) {\n                const label = [config.Environment, config.ReadOnly ? 'read-only' : ''].filter(Boolean).join(' · ');\n                if (!label) {\n                    return document.createTextNode('-');\n                }\n                const badge = document.createElement('span');\n                badge.className = 'px-2 py-1 rounded-md text-xs font-medium text-white';\n                badge.style.backgroundColor = config.Color;\n                badge.textContent = label;\n                return badge;\n            }\n\n            function deleteConfig(id) {\n                const config = configs.find(c => c.ID === id);\n                if (!config) {\n                    return;\n                }\n                const headers = { 'If-Match': `\"${config.Revision}\"` };\n                if (config.Protected) {\n                    const database = prompt(`${config.ConfigName} is protected. Type its database name to delete it:`);\n                    if (database === null) {\n                        return;\n                    }\n                    headers['X-Confirm-Database'] = database;\n                } else if (!confirm('Are you sure you want to delete this configuration?')) {\n                    return;\n                }\n                fetch(`/api/configs/${id}`, {\n                    method: 'DELETE',\n                    headers: headers\n                })\n                .then(response => {\n                    if (response.ok) {\n                        loadConfigs();\n                    } else if (response.status === 428) {\n                        alert('Database name did not match, configuration not deleted');\n                    } else if (response.status === 412) {\n                        alert('Configuration was changed elsewhere, reloading');\n                        loadConfigs();\n                    } else {\n                        alert('Error deleting configuration');\n                    }\n                })\n                .catch(error => console.error('Error:', error));\n            }\n        </script></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		return templ_7745c5c3_Err
 	})
 }
+
+var _ = templruntime.GeneratedTemplate